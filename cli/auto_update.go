@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"net/url"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/nod"
+)
+
+func AutoUpdateHandler(u *url.URL) error {
+
+	q := u.Query()
+
+	id := q.Get(vangogh_integration.UrlIdParameter)
+	remove := q.Has(vangogh_integration.UrlRemoveParameter)
+
+	return AutoUpdate(id, remove)
+}
+
+// AutoUpdate flags installed product to have updates applied by watch-updates
+func AutoUpdate(id string, remove bool) error {
+
+	aua := nod.Begin("setting auto-update for %s...", id)
+	defer aua.Done()
+
+	rdx, err := newReduxWriter(data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	if err = rdx.MustHave(data.InstallInfoProperty, data.AutoUpdateProperty); err != nil {
+		return err
+	}
+
+	if !rdx.HasKey(data.InstallInfoProperty, id) {
+		return ErrInstallInfoNotFound
+	}
+
+	if remove {
+		if rdx.HasKey(data.AutoUpdateProperty, id) {
+			if err = rdx.CutKeys(data.AutoUpdateProperty, id); err != nil {
+				return err
+			}
+		}
+		aua.EndWithResult("updates will not be applied automatically")
+		return nil
+	}
+
+	if err = rdx.ReplaceValues(data.AutoUpdateProperty, id, "true"); err != nil {
+		return err
+	}
+
+	aua.EndWithResult("updates will be applied automatically")
+
+	return nil
+}
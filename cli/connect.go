@@ -7,7 +7,6 @@ import (
 	"github.com/arelate/southern_light/vangogh_integration"
 	"github.com/arelate/theo/data"
 	"github.com/boggydigital/nod"
-	"github.com/boggydigital/redux"
 )
 
 func ConnectHandler(u *url.URL) error {
@@ -41,7 +40,7 @@ func Connect(urlStr, username, password, cookies string, origin data.Origin, res
 	ca := nod.Begin("setting up theo connection...")
 	defer ca.Done()
 
-	rdx, err := redux.NewWriter(data.AbsReduxDir(), data.AllProperties()...)
+	rdx, err := newReduxWriter(data.AllProperties()...)
 	if err != nil {
 		return err
 	}
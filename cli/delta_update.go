@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"encoding/json/v2"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/pathways"
+)
+
+const (
+	deltaUnchanged = "unchanged"
+	deltaChanged   = "changed"
+	deltaAdded     = "added"
+	deltaRemoved   = "removed"
+)
+
+// stageDeltaFiles moves installed files that will be replaced or removed, as well as
+// the current inventory, to the staging directory and records files that will be added,
+// so that the update can be reverted with restorePreviousInstallation
+func stageDeltaFiles(absStagingDir, absInstalledPath, absInventoryFilename string, delta map[string][]string) error {
+
+	sdfa := nod.Begin(" staging replaced files...")
+	defer sdfa.Done()
+
+	addedFile, err := os.Create(filepath.Join(absStagingDir, transactionDeltaAddedFilename))
+	if err != nil {
+		return err
+	}
+
+	err = json.MarshalWrite(addedFile, delta[deltaAdded])
+	if cerr := addedFile.Close(); cerr != nil {
+		return cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err = os.Stat(absInventoryFilename); absInventoryFilename != "" && err == nil {
+		if err = moveAll(absInventoryFilename, filepath.Join(absStagingDir, transactionInventoryFilename)); err != nil {
+			return err
+		}
+	}
+
+	absDeltaDir := filepath.Join(absStagingDir, transactionDeltaDir)
+
+	for _, relFile := range slices.Concat(delta[deltaChanged], delta[deltaRemoved]) {
+		if err = placeFile(filepath.Join(absInstalledPath, relFile), filepath.Join(absDeltaDir, relFile)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func placeFile(absSrcPath, absDstPath string) error {
+
+	if err := os.MkdirAll(filepath.Dir(absDstPath), pathways.PermUrwGrwOr); err != nil {
+		return err
+	}
+
+	return os.Rename(absSrcPath, absDstPath)
+}
+
+// removeEmptyDirs removes relative directory and its parents
+// inside the root, as long as they're empty
+func removeEmptyDirs(absRootPath, relDir string) error {
+
+	for relDir != "." && relDir != string(filepath.Separator) {
+
+		absDir := filepath.Join(absRootPath, relDir)
+
+		entries, err := os.ReadDir(absDir)
+		if os.IsNotExist(err) {
+			relDir = filepath.Dir(relDir)
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if len(entries) > 0 {
+			return nil
+		}
+
+		if err = os.Remove(absDir); err != nil {
+			return err
+		}
+
+		relDir = filepath.Dir(relDir)
+	}
+
+	return nil
+}
+
+// restoreDeltaFiles reverts files placed by a delta update: added files
+// are removed and staged files are moved back to the installed path
+func restoreDeltaFiles(absStagingDir, absInstalledPath string) error {
+
+	absAddedFilename := filepath.Join(absStagingDir, transactionDeltaAddedFilename)
+	if addedFile, err := os.Open(absAddedFilename); err == nil {
+
+		var relAdded []string
+		err = json.UnmarshalRead(addedFile, &relAdded)
+		if cerr := addedFile.Close(); cerr != nil {
+			return cerr
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, relFile := range relAdded {
+			absAddedFile := filepath.Join(absInstalledPath, relFile)
+			if _, err = os.Stat(absAddedFile); err == nil {
+				if err = os.Remove(absAddedFile); err != nil {
+					return err
+				}
+			}
+			if err = removeEmptyDirs(absInstalledPath, filepath.Dir(relFile)); err != nil {
+				return err
+			}
+		}
+	}
+
+	absDeltaDir := filepath.Join(absStagingDir, transactionDeltaDir)
+	if _, err := os.Stat(absDeltaDir); os.IsNotExist(err) {
+		return nil
+	}
+
+	relStaged, err := relWalkDir(absDeltaDir)
+	if err != nil {
+		return err
+	}
+
+	for _, relFile := range relStaged {
+		if err = placeFile(filepath.Join(absDeltaDir, relFile), filepath.Join(absInstalledPath, relFile)); err != nil {
+			return err
+		}
+	}
+
+	return os.RemoveAll(absDeltaDir)
+}
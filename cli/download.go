@@ -1,7 +1,9 @@
 package cli
 
 import (
+	"errors"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/arelate/southern_light/egs_integration"
@@ -11,6 +13,8 @@ import (
 	"github.com/boggydigital/redux"
 )
 
+const defaultDownloadConcurrency = 4
+
 func DownloadHandler(u *url.URL) error {
 
 	q := u.Query()
@@ -35,6 +39,11 @@ func DownloadHandler(u *url.URL) error {
 		force:           q.Has(vangogh_integration.UrlForceParameter),
 	}
 
+	var err error
+	if ii.concurrency, err = parseConcurrency(q); err != nil {
+		return err
+	}
+
 	if q.Has(vangogh_integration.UrlSteamParameter) {
 		ii.Origin = data.SteamOrigin
 	}
@@ -59,7 +68,7 @@ func Download(id string,
 	da := nod.Begin("downloading product data...")
 	defer da.Done()
 
-	rdx, err := redux.NewWriter(data.AbsReduxDir(), data.AllProperties()...)
+	rdx, err := newReduxWriter(data.AllProperties()...)
 	if err != nil {
 		return err
 	}
@@ -156,3 +165,21 @@ func originDownloadData(id string,
 		return ii.Origin.ErrUnsupportedOrigin()
 	}
 }
+
+func parseConcurrency(q url.Values) (int, error) {
+
+	if !q.Has(data.UrlConcurrencyParameter) {
+		return defaultDownloadConcurrency, nil
+	}
+
+	concurrency, err := strconv.Atoi(q.Get(data.UrlConcurrencyParameter))
+	if err != nil {
+		return 0, err
+	}
+
+	if concurrency < 1 {
+		return 0, errors.New("concurrency must be a positive number")
+	}
+
+	return concurrency, nil
+}
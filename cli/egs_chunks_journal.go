@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/boggydigital/pathways"
+)
+
+const egsChunksJournalFilename = "chunks.journal"
+
+// egsChunksJournal records chunks that were completely downloaded, so that
+// an interrupted download can resume without re-requesting them
+type egsChunksJournal struct {
+	file      *os.File
+	completed map[string]any
+}
+
+func openEgsChunksJournal(absChunksDownloadsDir string, reset bool) (*egsChunksJournal, error) {
+
+	if _, err := os.Stat(absChunksDownloadsDir); os.IsNotExist(err) {
+		if err = os.MkdirAll(absChunksDownloadsDir, pathways.PermUrwGrwOr); err != nil {
+			return nil, err
+		}
+	}
+
+	absJournalPath := filepath.Join(absChunksDownloadsDir, egsChunksJournalFilename)
+
+	if reset {
+		if err := os.Remove(absJournalPath); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	journalFile, err := os.OpenFile(absJournalPath, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	completed := make(map[string]any)
+
+	scanner := bufio.NewScanner(journalFile)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			completed[line] = nil
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return &egsChunksJournal{
+		file:      journalFile,
+		completed: completed,
+	}, nil
+}
+
+func (ecj *egsChunksJournal) Has(chunkPath string) bool {
+	_, ok := ecj.completed[chunkPath]
+	return ok
+}
+
+func (ecj *egsChunksJournal) Add(chunkPath string) error {
+	if _, err := io.WriteString(ecj.file, chunkPath+"\n"); err != nil {
+		return err
+	}
+	ecj.completed[chunkPath] = nil
+	return nil
+}
+
+func (ecj *egsChunksJournal) Close() error {
+	return ecj.file.Close()
+}
@@ -0,0 +1,472 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/arelate/southern_light/egs_integration"
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+// egsDeltaUpdate updates installed product by comparing the manifest of the installed
+// version to the latest manifest: only chunks of the changed files are downloaded, only
+// changed files are assembled and files that are not present in the latest manifest are
+// removed. Products without installed manifest are reinstalled
+func egsDeltaUpdate(appName string, ii *InstallInfo) error {
+
+	edua := nod.Begin("updating %s changed files...", appName)
+	defer edua.Done()
+
+	rdx, err := newReduxWriter(data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	absInstalledPath, err := originOsInstalledPath(appName, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	installedManifest, err := egsReadInstalledManifest(appName, ii.OperatingSystem)
+	if err != nil {
+		return err
+	}
+
+	if _, err = os.Stat(absInstalledPath); os.IsNotExist(err) || installedManifest == nil {
+		edua.EndWithResult("installed manifest is not available, reinstalling")
+		return Install(appName, ii)
+	}
+
+	if err = ii.backupMetadata(); err != nil {
+		return err
+	}
+
+	originData, err := originGetData(appName, ii, rdx, true)
+	if err != nil {
+		return err
+	}
+
+	pl, err := lockProduct(appName, ii, "updating")
+	if err != nil {
+		return err
+	}
+	defer pl.release()
+
+	it, err := beginInstallTransaction(appName, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	if err = egsDeltaUpdateSteps(appName, ii, originData, installedManifest, rdx, it); err != nil {
+		return it.rollback(err)
+	}
+
+	if err = it.commit(); err != nil {
+		return err
+	}
+
+	if !ii.KeepDownloads {
+		if err = RemoveDownloads(appName, ii, rdx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func egsDeltaUpdateSteps(appName string, ii *InstallInfo, originData *data.OriginData, installedManifest *egs_integration.Manifest, rdx redux.Writeable, it *installTransaction) error {
+
+	// DLCs are updated in the installation directory shared with the main product
+	if err := it.shareOuter(); err != nil {
+		return err
+	}
+
+	delta, err := egsDiffManifests(appName, ii, installedManifest, originData.Manifest, rdx)
+	if err != nil {
+		return err
+	}
+
+	changedFiles := egsManifestFiles(originData.Manifest, slices.Concat(delta[deltaChanged], delta[deltaAdded])...)
+	changedChunks := egsFilesChunks(changedFiles)
+
+	if err = egsDownloadChunkList(appName, ii, originData, changedChunks); err != nil {
+		return err
+	}
+
+	if _, err = egsValidateChunkList(appName, ii, originData, changedChunks); err != nil {
+		return err
+	}
+
+	if err = osPreInstallActions(appName, ii, rdx); err != nil {
+		return err
+	}
+
+	if err = it.do(stepAssembleChangedFiles,
+		func() error {
+			return egsAssembleChangedFiles(appName, ii, originData, changedFiles, delta, rdx, it.absStagingDir)
+		}); err != nil {
+		return err
+	}
+
+	if !ii.NoDlcs {
+		it.shareInstalledPath()
+		if err = it.do(stepUpdateDlcs,
+			func() error { return egsUpdateDownloadableContent(ii, originData.CatalogItem) }); err != nil {
+			return err
+		}
+	}
+
+	if err = it.do(stepPostInstallActions,
+		func() error { return originPostInstall(appName, ii, originData, rdx) }); err != nil {
+		return err
+	}
+
+	if err = it.do(stepPinInstallInfo,
+		func() error { return originPinInstallInfo(appName, ii, originData, rdx) }); err != nil {
+		return err
+	}
+
+	idInstalledDate := map[string][]string{appName: {time.Now().UTC().Format(time.RFC3339)}}
+	return it.do(stepSetInstallDate,
+		func() error { return rdx.BatchReplaceValues(data.InstallDateProperty, idInstalledDate) })
+}
+
+// egsDiffManifests compares files hashes in the installed and latest manifests,
+// installed files that are missing are considered changed
+func egsDiffManifests(appName string, ii *InstallInfo, installedManifest, latestManifest *egs_integration.Manifest, rdx redux.Readable) (map[string][]string, error) {
+
+	edma := nod.Begin(" comparing EGS manifests for %s...", appName)
+	defer edma.Done()
+
+	absInstalledPath, err := originOsInstalledPath(appName, ii, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	installedHashes := make(map[string][]byte, len(installedManifest.FileList.List))
+	for _, file := range installedManifest.FileList.List {
+		installedHashes[file.Filename] = file.ShaHash
+	}
+
+	latestFilenames := make(map[string]any, len(latestManifest.FileList.List))
+
+	delta := make(map[string][]string)
+
+	for _, file := range latestManifest.FileList.List {
+
+		latestFilenames[file.Filename] = nil
+
+		installedHash, ok := installedHashes[file.Filename]
+		if !ok {
+			delta[deltaAdded] = append(delta[deltaAdded], file.Filename)
+			continue
+		}
+
+		if _, err = os.Stat(filepath.Join(absInstalledPath, file.Filename)); os.IsNotExist(err) {
+			delta[deltaAdded] = append(delta[deltaAdded], file.Filename)
+		} else if !bytes.Equal(installedHash, file.ShaHash) {
+			delta[deltaChanged] = append(delta[deltaChanged], file.Filename)
+		} else {
+			delta[deltaUnchanged] = append(delta[deltaUnchanged], file.Filename)
+		}
+	}
+
+	for filename := range installedHashes {
+		if _, ok := latestFilenames[filename]; ok {
+			continue
+		}
+		if _, err = os.Stat(filepath.Join(absInstalledPath, filename)); err == nil {
+			delta[deltaRemoved] = append(delta[deltaRemoved], filename)
+		}
+	}
+
+	edma.EndWithResult("%d changed, %d added, %d removed file(s)",
+		len(delta[deltaChanged]), len(delta[deltaAdded]), len(delta[deltaRemoved]))
+
+	return delta, nil
+}
+
+func egsManifestFiles(manifest *egs_integration.Manifest, filenames ...string) []*egs_integration.File {
+
+	filenamesSet := make(map[string]any, len(filenames))
+	for _, filename := range filenames {
+		filenamesSet[filename] = nil
+	}
+
+	files := make([]*egs_integration.File, 0, len(filenames))
+
+	for ii := range manifest.FileList.List {
+		if _, ok := filenamesSet[manifest.FileList.List[ii].Filename]; ok {
+			files = append(files, &manifest.FileList.List[ii])
+		}
+	}
+
+	return files
+}
+
+// egsFilesChunks returns unique chunks referenced by the parts of the files
+func egsFilesChunks(files []*egs_integration.File) []*egs_integration.Chunk {
+
+	chunks := make(map[string]*egs_integration.Chunk)
+
+	for _, file := range files {
+		for _, part := range file.Parts {
+			if part.Chunk != nil {
+				chunks[part.Chunk.Uuid.String()] = part.Chunk
+			}
+		}
+	}
+
+	return slices.Collect(maps.Values(chunks))
+}
+
+func egsAssembleChangedFiles(appName string,
+	ii *InstallInfo,
+	originData *data.OriginData,
+	changedFiles []*egs_integration.File,
+	delta map[string][]string,
+	rdx redux.Readable,
+	absStagingDir string) error {
+
+	eacfa := nod.NewProgress("assembling changed EGS files for %s-%s...", appName, ii.OperatingSystem)
+	defer eacfa.Done()
+
+	absInstalledPath, err := originOsInstalledPath(appName, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	var totalSize uint64
+	for _, file := range changedFiles {
+		totalSize += file.Size
+	}
+
+	egsAppsDir, err := originLibraryDir(ii, data.EgsApps, rdx)
+	if err != nil {
+		return err
+	}
+
+	// replaced files are staged until the update is committed, so changed files require
+	// space in addition to the installed files. Updates are always forced, so force doesn't skip this
+	if ok, err := hasFreeSpaceForBytes(egsAppsDir, int64(totalSize)); err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("not enough space for %s at %s", appName, egsAppsDir)
+	}
+
+	if err = stageDeltaFiles(absStagingDir, absInstalledPath, "", delta); err != nil {
+		return err
+	}
+
+	eacfa.Total(totalSize)
+
+	absChunksDownloadsDir := data.AbsChunksDownloadDir(appName, ii.OperatingSystem)
+	featureLevel := originData.Manifest.Metadata.FeatureLevel
+
+	for _, file := range changedFiles {
+
+		if err = egsAssembleFile(file, featureLevel, absChunksDownloadsDir, absInstalledPath); err != nil {
+			return err
+		}
+
+		if err = egsValidateAssembledFile(absInstalledPath, file); err != nil {
+			return err
+		}
+
+		eacfa.Progress(file.Size)
+	}
+
+	for _, filename := range delta[deltaRemoved] {
+		if err = removeEmptyDirs(absInstalledPath, filepath.Dir(filename)); err != nil {
+			return err
+		}
+	}
+
+	summary := make(map[string][]string)
+	for _, dt := range []string{deltaChanged, deltaAdded, deltaRemoved, deltaUnchanged} {
+		summary[dt] = []string{strconv.Itoa(len(delta[dt])) + " file(s)"}
+	}
+
+	eacfa.EndWithSummary("delta update results:", summary)
+
+	return nil
+}
+
+func egsUpdateDownloadableContent(ii *InstallInfo, catalogItem *egs_integration.CatalogItem) error {
+
+	if len(catalogItem.DlcItemList) == 0 {
+		return nil
+	}
+
+	eudca := nod.Begin("updating DLCs for %s...", catalogItem.Title)
+	defer eudca.Done()
+
+	osGameAssets, err := egsGetGameAssets(ii.force)
+	if err != nil {
+		return err
+	}
+
+	dlcGameAssets, err := egsCatalogItemDlcGameAssets(osGameAssets, ii.OperatingSystem, catalogItem, ii.force)
+	if err != nil {
+		return err
+	}
+
+	ii.DownloadableContent = nil
+
+	for dlcAppName, dlcTitle := range dlcGameAssets {
+
+		// updating sets version and other properties of the updated product,
+		// so DLCs are updated with a copy of the main product install info
+		dlcIi := *ii
+		dlcIi.DownloadableContent = nil
+
+		if err = egsDeltaUpdate(dlcAppName, &dlcIi); err != nil {
+			return err
+		}
+
+		ii.DownloadableContent = append(ii.DownloadableContent, dlcTitle)
+	}
+
+	return nil
+}
+
+func egsAbsInstalledManifestFilename(appName string, operatingSystem vangogh_integration.OperatingSystem) string {
+	return filepath.Join(data.Pwd.AbsRelDirPath(data.InstalledManifests, data.Metadata),
+		fmt.Sprintf("%s-%s", appName, operatingSystem)+egs_integration.ManifestExt)
+}
+
+// egsPinInstalledManifest keeps a copy of the manifest used for installation,
+// as the latest manifest is cached under the same key when checking for updates
+func egsPinInstalledManifest(appName string, operatingSystem vangogh_integration.OperatingSystem) error {
+
+	absManifestFilename := filepath.Join(data.Pwd.AbsRelDirPath(data.Manifests, data.Metadata),
+		fmt.Sprintf("%s-%s", appName, operatingSystem)+egs_integration.ManifestExt)
+
+	manifestFile, err := os.Open(absManifestFilename)
+	if err != nil {
+		return err
+	}
+	defer manifestFile.Close()
+
+	installedManifestFile, err := os.Create(egsAbsInstalledManifestFilename(appName, operatingSystem))
+	if err != nil {
+		return err
+	}
+	defer installedManifestFile.Close()
+
+	_, err = io.Copy(installedManifestFile, manifestFile)
+	return err
+}
+
+// egsStageInstalledManifest keeps a copy of the pinned manifest in the staging directory,
+// so that it can be restored when the transaction that pins a new one is rolled back
+func egsStageInstalledManifest(absStagingDir, appName string, operatingSystem vangogh_integration.OperatingSystem) error {
+
+	absInstalledManifestFilename := egsAbsInstalledManifestFilename(appName, operatingSystem)
+
+	if _, err := os.Stat(absInstalledManifestFilename); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return copyAll(absInstalledManifestFilename, filepath.Join(absStagingDir, transactionInstalledManifestFilename))
+}
+
+// egsRestoreInstalledManifest restores staged manifest, or removes
+// the manifest pinned for a product that didn't have one before
+func egsRestoreInstalledManifest(absStagingDir, appName string, operatingSystem vangogh_integration.OperatingSystem) error {
+
+	absStagedManifestFilename := filepath.Join(absStagingDir, transactionInstalledManifestFilename)
+
+	if _, err := os.Stat(absStagedManifestFilename); os.IsNotExist(err) {
+		return egsRemoveInstalledManifest(appName, operatingSystem)
+	} else if err != nil {
+		return err
+	}
+
+	return copyAll(absStagedManifestFilename, egsAbsInstalledManifestFilename(appName, operatingSystem))
+}
+
+func egsReadInstalledManifest(appName string, operatingSystem vangogh_integration.OperatingSystem) (*egs_integration.Manifest, error) {
+
+	installedManifestFile, err := os.Open(egsAbsInstalledManifestFilename(appName, operatingSystem))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer installedManifestFile.Close()
+
+	return egs_integration.ReadManifest(installedManifestFile)
+}
+
+func egsRemoveInstalledManifest(appName string, operatingSystem vangogh_integration.OperatingSystem) error {
+
+	absInstalledManifestFilename := egsAbsInstalledManifestFilename(appName, operatingSystem)
+
+	if _, err := os.Stat(absInstalledManifestFilename); err == nil {
+		return os.Remove(absInstalledManifestFilename)
+	}
+
+	return nil
+}
+
+// egsSeedInstalledManifest pins cached manifest for the products installed before
+// installed manifests were pinned, as long as it matches the installed version.
+// Must be called before the latest manifest replaces the cached one
+func egsSeedInstalledManifest(appName string, operatingSystem vangogh_integration.OperatingSystem) error {
+
+	if _, err := os.Stat(egsAbsInstalledManifestFilename(appName, operatingSystem)); err == nil {
+		return nil
+	}
+
+	rdx, err := newReduxReader(data.InstallInfoProperty)
+	if err != nil {
+		return err
+	}
+
+	installedInfo, err := matchInstalledInfo(appName, &InstallInfo{
+		OperatingSystem: operatingSystem,
+		LangCode:        langCodeAny,
+		Origin:          data.EpicGamesOrigin,
+	}, rdx)
+	if errors.Is(err, ErrInstallInfoNotFound) || errors.Is(err, ErrInstallInfoTooMany) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	absManifestFilename := filepath.Join(data.Pwd.AbsRelDirPath(data.Manifests, data.Metadata),
+		fmt.Sprintf("%s-%s", appName, operatingSystem)+egs_integration.ManifestExt)
+
+	manifestFile, err := os.Open(absManifestFilename)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer manifestFile.Close()
+
+	cachedManifest, err := egs_integration.ReadManifest(manifestFile)
+	if err != nil {
+		return err
+	}
+
+	if installedInfo.Version == "" || egsManifestVersion(cachedManifest) != installedInfo.Version {
+		return nil
+	}
+
+	return egsPinInstalledManifest(appName, operatingSystem)
+}
@@ -34,6 +34,18 @@ const (
 	jsonCatalogItemPfx = "{\"id\""
 )
 
+const (
+	egsChunkDownloadAttempts = 3
+	egsChunkDownloadBackoff  = 2 * time.Second
+)
+
+const (
+	egsUnixExecutableFlag uint8 = 1 << 2
+	// egsAdditionalCommandLineAttribute is a catalog item custom attribute
+	// with the arguments that are added to the manifest launch command
+	egsAdditionalCommandLineAttribute = "AdditionalCommandLine"
+)
+
 var egsClient *http.Client
 var egsTokenVerifiedRecently bool
 
@@ -601,6 +613,9 @@ func egsGetManifest(appName string, gameManifest *egs_integration.GameManifest,
 	osAppNameKey := fmt.Sprintf("%s-%s", appName, operatingSystem)
 
 	if !kvManifests.Has(osAppNameKey) || force {
+		if err = egsSeedInstalledManifest(appName, operatingSystem); err != nil {
+			return nil, err
+		}
 		if err = egsFetchManifests(osAppNameKey, gameManifest, kvManifests); err != nil {
 			return nil, err
 		}
@@ -981,17 +996,20 @@ func egsManifestSize(manifest *egs_integration.Manifest) int64 {
 
 func egsAssembleValidateChunks(appName string, ii *InstallInfo, originData *data.OriginData, rdx redux.Readable) error {
 
-	egsAppsDir := data.Pwd.AbsDirPath(data.EgsApps)
+	egsAppsDir, err := originLibraryDir(ii, data.EgsApps, rdx)
+	if err != nil {
+		return err
+	}
 
-	if err := originHasFreeSpace(appName, egsAppsDir, ii, originData); err != nil {
+	if err = originHasFreeSpace(appName, egsAppsDir, ii, originData); err != nil {
 		return err
 	}
 
-	if err := egsAssembleChunks(appName, ii, originData, rdx); err != nil {
+	if err = egsAssembleChunks(appName, ii, originData, rdx); err != nil {
 		return err
 	}
 
-	if err := egsValidateAssembly(appName, ii, originData, rdx); err != nil {
+	if err = egsValidateAssembly(appName, ii, originData, rdx); err != nil {
 		return err
 	}
 
@@ -1093,50 +1111,92 @@ func egsUninstallDlcs(appName string, ii *InstallInfo, rdx redux.Writeable) erro
 	return nil
 }
 
-func egsValidateChunks(appName string, ii *InstallInfo, originData *data.OriginData) error {
+func egsValidateChunks(appName string, ii *InstallInfo, originData *data.OriginData) ([]*fileValidation, error) {
+	return egsValidateChunkList(appName, ii, originData, originData.Manifest.ChunkList.Chunks)
+}
+
+func egsValidateChunkList(appName string, ii *InstallInfo, originData *data.OriginData, chunks []*egs_integration.Chunk) ([]*fileValidation, error) {
 
 	evca := nod.NewProgress("validating EGS chunks for %s-%s...", appName, ii.OperatingSystem)
 	defer evca.Done()
 
-	evca.Total(uint64(egsManifestSize(originData.Manifest)))
+	var totalSize uint64
+	for _, chunk := range chunks {
+		totalSize += chunk.FileSize
+	}
+
+	evca.Total(totalSize)
 
 	absChunksDownloadsDir := data.AbsChunksDownloadDir(appName, ii.OperatingSystem)
 
-	for _, chunk := range originData.Manifest.ChunkList.Chunks {
+	results := make([]ValidationResult, 0, len(chunks))
+	fileValidations := make([]*fileValidation, 0, len(chunks))
+
+	var invalidChunks int
+
+	for _, chunk := range chunks {
 
 		chunkPath := chunk.Path(originData.Manifest.Metadata.FeatureLevel)
 
-		absChunkFilename := filepath.Join(absChunksDownloadsDir, chunkPath)
+		fv := &fileValidation{Filename: chunkPath}
 
-		chunkFile, err := os.Open(absChunkFilename)
+		vr, err := egsValidateChunk(filepath.Join(absChunksDownloadsDir, chunkPath), chunk)
 		if err != nil {
-			return err
+			fv.Error = err.Error()
 		}
 
-		chunkReader, err := egs_integration.ReadChunk(chunkFile)
-		if err != nil {
-			return err
+		if vr != ValResValid {
+			invalidChunks++
 		}
 
-		shaSum := sha1.New()
+		fv.Result = vr
 
-		if _, err = io.Copy(shaSum, chunkReader); err != nil {
-			return err
-		}
+		results = append(results, vr)
+		fileValidations = append(fileValidations, fv)
 
-		expectedShaSum := fmt.Sprintf("%x", chunk.ShaHash)
-		actualShaSum := fmt.Sprintf("%x", shaSum.Sum(nil))
+		evca.Progress(chunk.FileSize)
+	}
 
-		if expectedShaSum != actualShaSum {
-			return errors.New("failed validation for " + chunkPath)
-		}
+	evca.EndWithResult(summarizeValidationResults(results))
 
-		evca.Progress(chunk.FileSize)
+	if invalidChunks > 0 {
+		return fileValidations, fmt.Errorf("failed validation for %d EGS chunk(s)", invalidChunks)
 	}
 
-	evca.EndWithResult("valid")
+	return fileValidations, nil
+}
 
-	return nil
+func egsValidateChunk(absChunkFilename string, chunk *egs_integration.Chunk) (ValidationResult, error) {
+
+	if _, err := os.Stat(absChunkFilename); os.IsNotExist(err) {
+		return ValResFileNotFound, nil
+	}
+
+	chunkFile, err := os.Open(absChunkFilename)
+	if err != nil {
+		return ValResError, err
+	}
+	defer chunkFile.Close()
+
+	chunkReader, err := egs_integration.ReadChunk(chunkFile)
+	if err != nil {
+		return ValResError, err
+	}
+
+	shaSum := sha1.New()
+
+	if _, err = io.Copy(shaSum, chunkReader); err != nil {
+		return ValResError, err
+	}
+
+	expectedShaSum := fmt.Sprintf("%x", chunk.ShaHash)
+	actualShaSum := fmt.Sprintf("%x", shaSum.Sum(nil))
+
+	if expectedShaSum != actualShaSum {
+		return ValResMismatch, nil
+	}
+
+	return ValResValid, nil
 }
 
 func egsSetupConnection(cookieStr string, reset bool) error {
@@ -1198,54 +1258,167 @@ func egsResetConnection() error {
 
 func egsDownloadChunks(appName string, ii *InstallInfo, originData *data.OriginData) error {
 
-	edca := nod.NewProgress("downloading EGS chunks...")
-	edca.Done()
-
 	downloadsDir := data.Pwd.AbsDirPath(data.Downloads)
 
 	if err := originHasFreeSpace(appName, downloadsDir, ii, originData); err != nil {
 		return err
 	}
 
-	edca.Total(uint64(egsManifestSize(originData.Manifest)))
+	return egsDownloadChunkList(appName, ii, originData, originData.Manifest.ChunkList.Chunks)
+}
+
+func egsDownloadChunkList(appName string, ii *InstallInfo, originData *data.OriginData, chunks []*egs_integration.Chunk) error {
+
+	edca := nod.NewProgress("downloading EGS chunks...")
+	defer edca.Done()
 
 	cdnUrls, err := originData.GameManifest.Urls()
 	if err != nil {
 		return err
 	}
 
-	dc := dolo.DefaultClient
+	if len(cdnUrls) == 0 {
+		return errors.New("downloading EGS chunks requires CDN url")
+	}
 
-	var cdnUrl *url.URL
-	for _, cu := range cdnUrls {
-		cdnUrl = cu
-		break
+	absChunksDownloadsDir := data.AbsChunksDownloadDir(appName, ii.OperatingSystem)
+
+	journal, err := openEgsChunksJournal(absChunksDownloadsDir, ii.force)
+	if err != nil {
+		return err
 	}
+	defer journal.Close()
 
-	if cdnUrl == nil {
-		return errors.New("downloading EGS chunks requires CDN url")
+	featureLevel := originData.Manifest.Metadata.FeatureLevel
+
+	var totalSize, completedSize uint64
+	pendingChunks := make([]*egs_integration.Chunk, 0, len(chunks))
+
+	for _, chunk := range chunks {
+		totalSize += chunk.FileSize
+		chunkPath := chunk.Path(featureLevel)
+		if journal.Has(chunkPath) {
+			if _, err = os.Stat(filepath.Join(absChunksDownloadsDir, chunkPath)); err == nil {
+				completedSize += chunk.FileSize
+				continue
+			}
+		}
+		pendingChunks = append(pendingChunks, chunk)
 	}
 
-	absChunksDownloadsDir := data.AbsChunksDownloadDir(appName, ii.OperatingSystem)
+	edca.Total(totalSize)
+	edca.Progress(completedSize)
 
-	originalPath := strings.TrimSuffix(cdnUrl.Path, filepath.Base(cdnUrl.Path))
-	cdnUrl.RawQuery = ""
+	concurrency := ii.concurrency
+	if concurrency < 1 {
+		concurrency = defaultDownloadConcurrency
+	}
 
-	for _, chunk := range originData.Manifest.ChunkList.Chunks {
+	chunksCh := make(chan *egs_integration.Chunk)
+	resultsCh := make(chan *egsChunkDownloadResult)
 
-		chunkPath := chunk.Path(originData.Manifest.Metadata.FeatureLevel)
-		cdnUrl.Path = path.Join(originalPath, chunkPath)
+	// stops sending chunks and results when returning before all results are received
+	done := make(chan struct{})
+	defer close(done)
 
-		if err = dc.Download(cdnUrl, ii.force, nil, absChunksDownloadsDir, chunkPath); err != nil {
+	for range min(concurrency, max(len(pendingChunks), 1)) {
+		go func() {
+			for chunk := range chunksCh {
+				result := &egsChunkDownloadResult{
+					chunk: chunk,
+					err:   egsDownloadChunk(chunk.Path(featureLevel), cdnUrls, absChunksDownloadsDir, ii.force),
+				}
+				select {
+				case resultsCh <- result:
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(chunksCh)
+		for _, chunk := range pendingChunks {
+			select {
+			case chunksCh <- chunk:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var failedChunks []string
+
+	for range pendingChunks {
+		result := <-resultsCh
+
+		chunkPath := result.chunk.Path(featureLevel)
+
+		if result.err != nil {
+			failedChunks = append(failedChunks, chunkPath+": "+result.err.Error())
+			continue
+		}
+
+		if err = journal.Add(chunkPath); err != nil {
 			return err
 		}
 
-		edca.Progress(chunk.FileSize)
+		edca.Progress(result.chunk.FileSize)
+	}
+
+	if len(failedChunks) > 0 {
+		return fmt.Errorf("failed to download %d EGS chunk(s):\n%s", len(failedChunks), strings.Join(failedChunks, "\n"))
 	}
 
 	return nil
 }
 
+type egsChunkDownloadResult struct {
+	chunk *egs_integration.Chunk
+	err   error
+}
+
+func egsDownloadChunk(chunkPath string, cdnUrls []*url.URL, absChunksDownloadsDir string, force bool) error {
+
+	dc := dolo.DefaultClient
+
+	var err error
+	for attempt := range egsChunkDownloadAttempts {
+
+		if attempt > 0 {
+			time.Sleep(egsChunkDownloadBackoff << (attempt - 1))
+		}
+
+		for _, cdnUrl := range cdnUrls {
+			if err = dc.Download(egsChunkUrl(cdnUrl, chunkPath), force, nil, absChunksDownloadsDir, chunkPath); err == nil {
+				return nil
+			}
+		}
+	}
+
+	return err
+}
+
+func egsChunkUrl(cdnUrl *url.URL, chunkPath string) *url.URL {
+
+	chunkUrl := *cdnUrl
+
+	chunkUrl.Path = path.Join(strings.TrimSuffix(cdnUrl.Path, path.Base(cdnUrl.Path)), chunkPath)
+	chunkUrl.RawQuery = ""
+
+	return &chunkUrl
+}
+
+// egsLaunchTask is a named launch configuration, tasks are created for every
+// manifest executable and catalog item launch attribute
+type egsLaunchTask struct {
+	name      string
+	exe       string
+	args      []string
+	isPrimary bool
+}
+
 func egsGetExecTask(appName string, ii *InstallInfo, originData *data.OriginData, rdx redux.Writeable, et *execTask) (*execTask, error) {
 
 	installedPath, err := originOsInstalledPath(appName, ii, rdx)
@@ -1253,20 +1426,90 @@ func egsGetExecTask(appName string, ii *InstallInfo, originData *data.OriginData
 		return nil, err
 	}
 
-	absPrefixDir, err := data.AbsPrefixDir(appName, ii.Origin, rdx)
+	absPrefixDir, err := data.AbsPrefixDir(appName, ii.Origin, ii.Library, rdx)
 	if err != nil {
 		return nil, err
 	}
 
-	launchDir, launchFile := filepath.Split(originData.Manifest.Metadata.LaunchExe)
+	var launchTask *egsLaunchTask
+
+	for _, lt := range egsGetLaunchTasks(originData) {
+		if (et.task == "" && lt.isPrimary) || (et.task != "" && strings.EqualFold(lt.name, et.task)) {
+			launchTask = lt
+			break
+		}
+	}
+
+	if launchTask == nil {
+		if et.task != "" {
+			return nil, errors.New("named egs launch task not found for " + appName)
+		}
+		return nil, errors.New("egs launch executable not found for " + appName)
+	}
+
+	launchDir, launchFile := filepath.Split(launchTask.exe)
 
 	et.title = launchFile
-	et.prefix = absPrefixDir
-	et.exe = filepath.Join(installedPath, originData.Manifest.Metadata.LaunchExe)
-	if originData.Manifest.Metadata.LaunchCommand != "" {
-		et.args = append(et.args, originData.Manifest.Metadata.LaunchCommand)
+	if et.task != "" {
+		et.title = launchTask.name
 	}
+	et.prefix = absPrefixDir
+	et.exe = filepath.Join(installedPath, launchTask.exe)
+	et.args = append(et.args, launchTask.args...)
 	et.workDir = filepath.Join(installedPath, launchDir)
 
 	return et, nil
 }
+
+// egsGetLaunchTasks returns manifest executables as tasks, manifest launch executable
+// is the primary task. Catalog item additional command line is added as a separate
+// task for the launch executable
+func egsGetLaunchTasks(originData *data.OriginData) []*egsLaunchTask {
+
+	metadata := originData.Manifest.Metadata
+
+	var launchArgs []string
+	if metadata.LaunchCommand != "" {
+		launchArgs = append(launchArgs, metadata.LaunchCommand)
+	}
+
+	launchTasks := make([]*egsLaunchTask, 0)
+
+	if metadata.LaunchExe != "" {
+		launchTasks = append(launchTasks, &egsLaunchTask{
+			name:      metadata.LaunchExe,
+			exe:       metadata.LaunchExe,
+			args:      launchArgs,
+			isPrimary: true,
+		})
+	}
+
+	for _, file := range originData.Manifest.FileList.List {
+
+		if file.Filename == metadata.LaunchExe {
+			continue
+		}
+
+		if !strings.EqualFold(filepath.Ext(file.Filename), ".exe") &&
+			file.Flags&egsUnixExecutableFlag == 0 {
+			continue
+		}
+
+		launchTasks = append(launchTasks, &egsLaunchTask{
+			name: file.Filename,
+			exe:  file.Filename,
+		})
+	}
+
+	if originData.CatalogItem != nil && metadata.LaunchExe != "" {
+		if acl, ok := originData.CatalogItem.CustomAttributes[egsAdditionalCommandLineAttribute]; ok && acl.Value != "" {
+			launchTasks = append(launchTasks, &egsLaunchTask{
+				name: egsAdditionalCommandLineAttribute,
+				exe:  metadata.LaunchExe,
+				args: append(slices.Clone(launchArgs), strings.Fields(acl.Value)...),
+			})
+		}
+	}
+
+	return launchTasks
+}
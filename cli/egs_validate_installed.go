@@ -0,0 +1,236 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/arelate/southern_light/egs_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+// egsValidateInstalled hashes installed files and compares them to the manifest
+// of the installed version, which doesn't require chunks to be kept. Repair downloads
+// only the chunks of the files that failed validation and reassembles those files
+func egsValidateInstalled(appName string, ii *InstallInfo, repair bool, rdx redux.Writeable) ([]*fileValidation, error) {
+
+	originData, err := originGetData(appName, ii, rdx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// cached manifest might be newer than the installed version
+	installedManifest, err := egsReadInstalledManifest(appName, ii.OperatingSystem)
+	if err != nil {
+		return nil, err
+	} else if installedManifest == nil {
+		installedManifest = originData.Manifest
+	}
+
+	absInstalledPath, err := originOsInstalledPath(appName, ii, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	installedFiles := make([]*egs_integration.File, 0, len(installedManifest.FileList.List))
+	for i := range installedManifest.FileList.List {
+		installedFiles = append(installedFiles, &installedManifest.FileList.List[i])
+	}
+
+	fileValidations := egsValidateInstalledFiles(appName, ii, absInstalledPath, installedFiles)
+
+	invalidFilenames := make([]string, 0)
+	for _, fv := range fileValidations {
+		if fv.Result != ValResValid {
+			invalidFilenames = append(invalidFilenames, fv.Filename)
+		}
+	}
+
+	if repair && len(invalidFilenames) > 0 {
+
+		var repaired map[string]any
+		repaired, err = egsRepairInstalledFiles(appName, ii, installedManifest, invalidFilenames, absInstalledPath, rdx)
+		if err != nil {
+			return fileValidations, err
+		}
+
+		for _, fv := range fileValidations {
+			if _, ok := repaired[fv.Filename]; ok {
+				fv.Result = ValResValid
+				fv.Error = ""
+			}
+		}
+
+		invalidFilenames = invalidFilenames[:0]
+		for _, fv := range fileValidations {
+			if fv.Result != ValResValid {
+				invalidFilenames = append(invalidFilenames, fv.Filename)
+			}
+		}
+	}
+
+	if len(invalidFilenames) > 0 {
+		return fileValidations, fmt.Errorf("failed validation for %d installed EGS file(s)", len(invalidFilenames))
+	}
+
+	return fileValidations, nil
+}
+
+func egsValidateInstalledFiles(appName string, ii *InstallInfo, absInstalledPath string, files []*egs_integration.File) []*fileValidation {
+
+	evifa := nod.NewProgress("validating installed EGS files for %s-%s...", appName, ii.OperatingSystem)
+	defer evifa.Done()
+
+	var totalSize uint64
+	for _, file := range files {
+		totalSize += file.Size
+	}
+
+	evifa.Total(totalSize)
+
+	results := make([]ValidationResult, 0, len(files))
+	fileValidations := make([]*fileValidation, 0, len(files))
+
+	for _, file := range files {
+
+		fv := &fileValidation{Filename: file.Filename}
+
+		vr, err := egsValidateInstalledFile(filepath.Join(absInstalledPath, file.Filename), file)
+		if err != nil {
+			fv.Error = err.Error()
+		}
+
+		fv.Result = vr
+
+		results = append(results, vr)
+		fileValidations = append(fileValidations, fv)
+
+		evifa.Progress(file.Size)
+	}
+
+	evifa.EndWithResult(summarizeValidationResults(results))
+
+	return fileValidations
+}
+
+func egsValidateInstalledFile(absFilename string, file *egs_integration.File) (ValidationResult, error) {
+
+	if _, err := os.Stat(absFilename); os.IsNotExist(err) {
+		return ValResFileNotFound, nil
+	}
+
+	installedFile, err := os.Open(absFilename)
+	if err != nil {
+		return ValResError, err
+	}
+	defer installedFile.Close()
+
+	shaSum := sha1.New()
+
+	if _, err = io.Copy(shaSum, installedFile); err != nil {
+		return ValResError, err
+	}
+
+	if !bytes.Equal(shaSum.Sum(nil), file.ShaHash) {
+		return ValResMismatch, nil
+	}
+
+	return ValResValid, nil
+}
+
+// egsRepairInstalledFiles reassembles invalid files from the chunks of the latest
+// manifest. Files that are different in the latest version require product update
+// and are not repaired
+func egsRepairInstalledFiles(appName string,
+	ii *InstallInfo,
+	installedManifest *egs_integration.Manifest,
+	filenames []string,
+	absInstalledPath string,
+	rdx redux.Writeable) (map[string]any, error) {
+
+	erifa := nod.Begin("repairing %s...", appName)
+	defer erifa.Done()
+
+	title, err := data.GetTitleProperty(appName, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	if running, err := newRunState(appName, title, ii).isRunning(); err != nil {
+		return nil, err
+	} else if running {
+		return nil, errors.New(title + " is running, please stop it before repairing")
+	}
+
+	originData, err := originGetData(appName, ii, rdx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	pl, err := lockProduct(appName, ii, "repairing")
+	if err != nil {
+		return nil, err
+	}
+	defer pl.release()
+
+	installedHashes := make(map[string][]byte, len(filenames))
+	for _, file := range egsManifestFiles(installedManifest, filenames...) {
+		installedHashes[file.Filename] = file.ShaHash
+	}
+
+	repairableFiles := make([]*egs_integration.File, 0, len(filenames))
+	for _, file := range egsManifestFiles(originData.Manifest, filenames...) {
+		if bytes.Equal(file.ShaHash, installedHashes[file.Filename]) {
+			repairableFiles = append(repairableFiles, file)
+		}
+	}
+
+	repaired := make(map[string]any, len(repairableFiles))
+
+	if len(repairableFiles) > 0 {
+
+		repairChunks := egsFilesChunks(repairableFiles)
+
+		if err = egsDownloadChunkList(appName, ii, originData, repairChunks); err != nil {
+			return nil, err
+		}
+
+		if _, err = egsValidateChunkList(appName, ii, originData, repairChunks); err != nil {
+			return nil, err
+		}
+
+		absChunksDownloadsDir := data.AbsChunksDownloadDir(appName, ii.OperatingSystem)
+		featureLevel := originData.Manifest.Metadata.FeatureLevel
+
+		for _, file := range repairableFiles {
+
+			if err = egsAssembleFile(file, featureLevel, absChunksDownloadsDir, absInstalledPath); err != nil {
+				return nil, err
+			}
+
+			if err = egsValidateAssembledFile(absInstalledPath, file); err != nil {
+				return nil, err
+			}
+
+			repaired[file.Filename] = nil
+		}
+
+		if !ii.KeepDownloads {
+			if err = egsRemoveChunks(appName, ii.OperatingSystem, originData); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(repaired) < len(filenames) {
+		erifa.EndWithResult("some files don't match the latest version, update the product to repair them")
+	}
+
+	return repaired, nil
+}
@@ -7,7 +7,6 @@ import (
 	"github.com/arelate/southern_light/vangogh_integration"
 	"github.com/arelate/theo/data"
 	"github.com/boggydigital/nod"
-	"github.com/boggydigital/redux"
 )
 
 func FetchDataHandler(u *url.URL) error {
@@ -41,7 +40,7 @@ func FetchData(id string, ii *InstallInfo) error {
 	fda := nod.Begin("fetching data for %s, %s from %s...", id, ii.OperatingSystem, ii.Origin)
 	defer fda.Done()
 
-	rdx, err := redux.NewWriter(data.AbsReduxDir(), data.AllProperties()...)
+	rdx, err := newReduxWriter(data.AllProperties()...)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,526 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/pathways"
+	"github.com/boggydigital/redux"
+)
+
+const (
+	gcPrefixes     = "prefixes"
+	gcInventory    = "inventory"
+	gcUmuConfigs   = "umu-configs"
+	gcTemp         = "temp"
+	gcEgsChunks    = "egs-chunks"
+	gcWineBinaries = "wine-binaries"
+)
+
+func GcCategories() []string {
+	return []string{
+		gcPrefixes,
+		gcInventory,
+		gcUmuConfigs,
+		gcTemp,
+		gcEgsChunks,
+		gcWineBinaries,
+	}
+}
+
+// prefixes and inventory are named by product titles. Title changes leave prefixes and
+// inventory of installed products looking orphaned, so they're only removed when confirmed
+var gcConfirmCategories = []string{gcPrefixes, gcInventory}
+
+var gcCollectors = map[string]func(installedInfos map[string][]InstallInfo, rdx redux.Readable) ([]string, error){
+	gcPrefixes:     gcCollectPrefixes,
+	gcInventory:    gcCollectInventory,
+	gcUmuConfigs:   gcCollectUmuConfigs,
+	gcTemp:         gcCollectTemp,
+	gcEgsChunks:    gcCollectEgsChunks,
+	gcWineBinaries: gcCollectWineBinaries,
+}
+
+type gcItem struct {
+	absPath string
+	bytes   int64
+}
+
+func GcHandler(u *url.URL) error {
+
+	q := u.Query()
+
+	var categories []string
+	if q.Has(data.UrlCategoryParameter) {
+		categories = strings.Split(q.Get(data.UrlCategoryParameter), ",")
+	}
+
+	remove := q.Has(vangogh_integration.UrlRemoveParameter)
+	interactive := q.Has(data.UrlInteractiveParameter)
+
+	return Gc(categories, remove, interactive)
+}
+
+// Gc reports artifacts left behind by removed products and outdated binaries
+// and the space that can be reclaimed by removing them. Artifacts are only
+// removed when requested, either all at once or confirming each one. Prefixes
+// and inventory are only removed when confirmed, see gcConfirmCategories
+func Gc(categories []string, remove, interactive bool) error {
+
+	ga := nod.Begin("collecting garbage...")
+	defer ga.Done()
+
+	if len(categories) == 0 {
+		categories = GcCategories()
+	}
+
+	for _, category := range categories {
+		if _, ok := gcCollectors[category]; !ok {
+			return errors.New("unknown gc category: " + category)
+		}
+	}
+
+	rdx, err := newReduxWriter(data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	installedInfos, err := getInstalledInfos(rdx)
+	if err != nil {
+		return err
+	}
+
+	gcItems := make(map[string][]gcItem)
+
+	for _, category := range categories {
+
+		absPaths, err := gcCollectors[category](installedInfos, rdx)
+		if err != nil {
+			return err
+		}
+
+		for _, absPath := range absPaths {
+			bytes, err := pathBytes(absPath)
+			if err != nil {
+				return err
+			}
+			gcItems[category] = append(gcItems[category], gcItem{absPath: absPath, bytes: bytes})
+		}
+	}
+
+	var totalBytes int64
+	summary := make(map[string][]string)
+
+	for category, items := range gcItems {
+
+		var categoryBytes int64
+		for _, item := range items {
+			categoryBytes += item.bytes
+		}
+		totalBytes += categoryBytes
+
+		heading := fmt.Sprintf("%s (%s)", category, vangogh_integration.FormatBytes(categoryBytes))
+		for _, item := range items {
+			summary[heading] = append(summary[heading],
+				fmt.Sprintf("%s (%s)", item.absPath, vangogh_integration.FormatBytes(item.bytes)))
+		}
+	}
+
+	if len(summary) == 0 {
+		ga.EndWithResult("already clean")
+		return nil
+	}
+
+	ga.EndWithSummary(fmt.Sprintf("reclaimable %s:", vangogh_integration.FormatBytes(totalBytes)), summary)
+
+	if !remove && !interactive {
+		return nil
+	}
+
+	// products installed right now have their artifacts pinned only at the
+	// end of installation, and would be considered orphaned until then
+	heldProductLocks, err := getHeldProductLocks()
+	if err != nil {
+		return err
+	}
+
+	if len(heldProductLocks) > 0 {
+		return errors.New("products are being installed, please try again later: " + strings.Join(heldProductLocks, ", "))
+	}
+
+	var removeItems []gcItem
+	var unconfirmed []string
+	stdin := bufio.NewReader(os.Stdin)
+
+	for _, category := range slices.Sorted(maps.Keys(gcItems)) {
+
+		if !interactive && slices.Contains(gcConfirmCategories, category) {
+			unconfirmed = append(unconfirmed, category)
+			continue
+		}
+
+		for _, item := range gcItems[category] {
+
+			if interactive {
+				var confirmed bool
+				if confirmed, err = gcConfirmRemove(item, stdin); err != nil {
+					return err
+				} else if !confirmed {
+					continue
+				}
+			}
+
+			removeItems = append(removeItems, item)
+		}
+	}
+
+	if len(unconfirmed) > 0 {
+		gka := nod.Begin("keeping %s...", strings.Join(unconfirmed, ", "))
+		gka.EndWithResult("use interactive to confirm removal")
+		gka.Done()
+	}
+
+	return gcRemoveItems(removeItems)
+}
+
+func gcConfirmRemove(item gcItem, stdin *bufio.Reader) (bool, error) {
+
+	fmt.Printf("remove %s (%s)? [y/N] ", item.absPath, vangogh_integration.FormatBytes(item.bytes))
+
+	answer, err := stdin.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+func gcRemoveItems(items []gcItem) error {
+
+	gria := nod.NewProgress("removing garbage...")
+	defer gria.Done()
+
+	gria.TotalInt(len(items))
+
+	var removedBytes int64
+
+	for _, item := range items {
+		if err := os.RemoveAll(item.absPath); err != nil {
+			return err
+		}
+		removedBytes += item.bytes
+		gria.Increment()
+	}
+
+	gria.EndWithResult("reclaimed %s", vangogh_integration.FormatBytes(removedBytes))
+
+	return nil
+}
+
+// getInstalledInfos returns installed infos for each installed product
+func getInstalledInfos(rdx redux.Readable) (map[string][]InstallInfo, error) {
+
+	if err := rdx.MustHave(data.InstallInfoProperty); err != nil {
+		return nil, err
+	}
+
+	installedInfos := make(map[string][]InstallInfo)
+
+	for id := range rdx.Keys(data.InstallInfoProperty) {
+
+		installedInfoLines, ok := rdx.GetAllValues(data.InstallInfoProperty, id)
+		if !ok {
+			continue
+		}
+
+		iis, err := unmarshalInstalledInfoLines(installedInfoLines...)
+		if err != nil {
+			return nil, err
+		}
+
+		installedInfos[id] = iis
+	}
+
+	return installedInfos, nil
+}
+
+// gcCollectUnexpected returns entries of a directory that are not expected.
+// Missing directories (e.g. not yet created in a library) have nothing to collect
+func gcCollectUnexpected(absDir string, expected map[string]any, filter func(entry os.DirEntry) bool) ([]string, error) {
+
+	entries, err := os.ReadDir(absDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	unexpected := make([]string, 0)
+
+	for _, entry := range entries {
+
+		if filter != nil && !filter(entry) {
+			continue
+		}
+
+		absPath := filepath.Join(absDir, entry.Name())
+		if _, ok := expected[absPath]; ok {
+			continue
+		}
+
+		unexpected = append(unexpected, absPath)
+	}
+
+	return unexpected, nil
+}
+
+func gcCollectPrefixes(installedInfos map[string][]InstallInfo, rdx redux.Readable) ([]string, error) {
+
+	expected := make(map[string]any)
+
+	for id, iis := range installedInfos {
+		for _, ii := range iis {
+			absPrefixDir, err := data.AbsPrefixDir(id, ii.Origin, ii.Library, rdx)
+			if err != nil {
+				return nil, err
+			}
+			expected[absPrefixDir] = nil
+		}
+	}
+
+	var orphaned []string
+
+	for _, library := range getLibraries(rdx) {
+
+		wineDir, err := data.AbsLibraryDir(library, data.Wine, rdx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, prefixesDir := range []pathways.RelDir{data.Prefixes, data.SteamPrefixes, data.EgsPrefixes} {
+
+			unexpected, err := gcCollectUnexpected(filepath.Join(wineDir, string(prefixesDir)), expected,
+				func(entry os.DirEntry) bool { return entry.IsDir() })
+			if err != nil {
+				return nil, err
+			}
+
+			orphaned = append(orphaned, unexpected...)
+		}
+	}
+
+	return orphaned, nil
+}
+
+func gcCollectInventory(installedInfos map[string][]InstallInfo, rdx redux.Readable) ([]string, error) {
+
+	expected := make(map[string]any)
+
+	for id, iis := range installedInfos {
+		for _, ii := range iis {
+
+			if ii.Origin != data.VangoghOrigin {
+				continue
+			}
+
+			absInventoryFilename, err := data.AbsInventoryFilename(id, ii.LangCode, ii.OperatingSystem, rdx)
+			if err != nil {
+				return nil, err
+			}
+			expected[absInventoryFilename] = nil
+		}
+	}
+
+	inventoryDir := data.Pwd.AbsRelDirPath(data.Inventory, data.InstalledApps)
+
+	osLangDirs, err := os.ReadDir(inventoryDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var orphaned []string
+
+	for _, osLangDir := range osLangDirs {
+
+		if !osLangDir.IsDir() {
+			continue
+		}
+
+		unexpected, err := gcCollectUnexpected(filepath.Join(inventoryDir, osLangDir.Name()), expected,
+			func(entry os.DirEntry) bool { return !entry.IsDir() })
+		if err != nil {
+			return nil, err
+		}
+
+		orphaned = append(orphaned, unexpected...)
+	}
+
+	return orphaned, nil
+}
+
+func gcCollectUmuConfigs(_ map[string][]InstallInfo, rdx redux.Readable) ([]string, error) {
+
+	latestUmuConfigsDir, err := getLatestUmuConfigsDir(rdx)
+	if err != nil {
+		// without known umu-launcher version all configs are kept
+		return nil, nil
+	}
+
+	expected := map[string]any{latestUmuConfigsDir: nil}
+
+	return gcCollectUnexpected(data.Pwd.AbsRelDirPath(data.UmuConfigs, data.Wine), expected,
+		func(entry os.DirEntry) bool { return entry.IsDir() })
+}
+
+func gcCollectTemp(_ map[string][]InstallInfo, rdx redux.Readable) ([]string, error) {
+
+	// interrupted transactions are kept, as they are
+	// recovered during the next product installation,
+	// other reserved directories are used by theo processes
+	reserved := []string{
+		string(data.Transactions),
+		string(data.MetadataRestores),
+		string(data.RunStates),
+		string(data.Locks),
+		string(data.Serve),
+	}
+
+	var leftovers []string
+
+	for _, library := range getLibraries(rdx) {
+
+		tempDir, err := data.AbsLibraryDir(library, data.Temp, rdx)
+		if err != nil {
+			return nil, err
+		}
+
+		unexpected, err := gcCollectUnexpected(tempDir, nil,
+			func(entry os.DirEntry) bool { return !slices.Contains(reserved, entry.Name()) })
+		if err != nil {
+			return nil, err
+		}
+
+		leftovers = append(leftovers, unexpected...)
+	}
+
+	return leftovers, nil
+}
+
+func gcCollectEgsChunks(installedInfos map[string][]InstallInfo, rdx redux.Readable) ([]string, error) {
+
+	if err := rdx.MustHave(vangogh_integration.EgsTitleProperty, vangogh_integration.EgsMainGameProperty); err != nil {
+		return nil, err
+	}
+
+	// only chunks of the installed products are garbage: chunks of the products that
+	// are downloaded without installation or are being installed are expected,
+	// as are chunks of the products installed with keep-downloads
+	expected := make(map[string]any)
+	installedChunks := make(map[string]any)
+
+	for appName := range rdx.Keys(vangogh_integration.EgsTitleProperty) {
+
+		mainAppName := appName
+		if requiresGame, ok := rdx.GetLastVal(vangogh_integration.EgsMainGameProperty, appName); ok && requiresGame != "" {
+			mainAppName = requiresGame
+		}
+
+		for _, ii := range installedInfos[mainAppName] {
+
+			if ii.Origin != data.EpicGamesOrigin {
+				continue
+			}
+
+			absChunksDir := data.AbsChunksDownloadDir(appName, ii.OperatingSystem)
+
+			locked, err := isProductLocked(mainAppName, &ii)
+			if err != nil {
+				return nil, err
+			}
+
+			switch {
+			case ii.KeepDownloads || locked:
+				expected[absChunksDir] = nil
+			default:
+				installedChunks[absChunksDir] = nil
+			}
+		}
+	}
+
+	isChunksDir := func(entry os.DirEntry) bool {
+
+		if !entry.IsDir() {
+			return false
+		}
+
+		for _, operatingSystem := range vangogh_integration.AllOperatingSystems() {
+			if appName, ok := strings.CutSuffix(entry.Name(), "-"+operatingSystem.String()); ok {
+				return rdx.HasKey(vangogh_integration.EgsTitleProperty, appName)
+			}
+		}
+
+		return false
+	}
+
+	unexpected, err := gcCollectUnexpected(data.Pwd.AbsDirPath(data.Downloads), expected, isChunksDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var leftovers []string
+	for _, absChunksDir := range unexpected {
+		if _, ok := installedChunks[absChunksDir]; ok {
+			leftovers = append(leftovers, absChunksDir)
+		}
+	}
+
+	return leftovers, nil
+}
+
+func gcCollectWineBinaries(_ map[string][]InstallInfo, rdx redux.Readable) ([]string, error) {
+
+	if err := rdx.MustHave(data.WineBinariesVersionsProperty); err != nil {
+		return nil, err
+	}
+
+	wineBinaries := data.Pwd.AbsRelDirPath(data.BinUnpacks, data.Wine)
+
+	var outdated []string
+
+	for title := range rdx.Keys(data.WineBinariesVersionsProperty) {
+
+		currentVersion, ok := rdx.GetLastVal(data.WineBinariesVersionsProperty, title)
+		if !ok || currentVersion == "" {
+			continue
+		}
+
+		absTitleDir := filepath.Join(wineBinaries, pathways.Sanitize(title))
+		expected := map[string]any{filepath.Join(absTitleDir, currentVersion): nil}
+
+		unexpected, err := gcCollectUnexpected(absTitleDir, expected,
+			func(entry os.DirEntry) bool { return entry.IsDir() })
+		if err != nil {
+			return nil, err
+		}
+
+		outdated = append(outdated, unexpected...)
+	}
+
+	return outdated, nil
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"net/url"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+// heldUnknownVersion is used to hold installations that don't have version information
+const heldUnknownVersion = "unknown"
+
+func HoldHandler(u *url.URL) error {
+
+	id, ii := holdParameters(u)
+
+	return Hold(id, ii)
+}
+
+func UnholdHandler(u *url.URL) error {
+
+	id, ii := holdParameters(u)
+
+	return Unhold(id, ii)
+}
+
+func holdParameters(u *url.URL) (string, *InstallInfo) {
+
+	q := u.Query()
+
+	id := q.Get(vangogh_integration.UrlIdParameter)
+
+	ii := &InstallInfo{
+		OperatingSystem: vangogh_integration.ParseOperatingSystem(q.Get(vangogh_integration.UrlOperatingSystemParameter)),
+		LangCode:        q.Get(vangogh_integration.UrlLanguageCodeParameter),
+	}
+
+	return id, ii
+}
+
+// Hold keeps installed product at the current version: updates will be
+// reported, but not installed until the product is unheld
+func Hold(id string, request *InstallInfo) error {
+
+	ha := nod.Begin("holding %s at the installed version...", id)
+	defer ha.Done()
+
+	rdx, err := newReduxWriter(data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	if err = rdx.MustHave(data.HoldProperty); err != nil {
+		return err
+	}
+
+	ii, err := matchInstalledInfo(id, request, rdx)
+	if err != nil {
+		return err
+	}
+
+	version := ii.Version
+	if version == "" {
+		version = heldUnknownVersion
+	}
+
+	appOsLangCode := data.AppOsLangCode(id, ii.OperatingSystem, ii.LangCode)
+
+	if err = rdx.ReplaceValues(data.HoldProperty, appOsLangCode, version); err != nil {
+		return err
+	}
+
+	ha.EndWithResult("held at version: %s", version)
+
+	return nil
+}
+
+func Unhold(id string, request *InstallInfo) error {
+
+	ua := nod.Begin("unholding %s...", id)
+	defer ua.Done()
+
+	rdx, err := newReduxWriter(data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	if err = rdx.MustHave(data.HoldProperty); err != nil {
+		return err
+	}
+
+	ii, err := matchInstalledInfo(id, request, rdx)
+	if err != nil {
+		return err
+	}
+
+	appOsLangCode := data.AppOsLangCode(id, ii.OperatingSystem, ii.LangCode)
+
+	if !rdx.HasKey(data.HoldProperty, appOsLangCode) {
+		ua.EndWithResult("not held")
+		return nil
+	}
+
+	if err = rdx.CutKeys(data.HoldProperty, appOsLangCode); err != nil {
+		return err
+	}
+
+	ua.EndWithResult("updates will be installed")
+
+	return nil
+}
+
+func heldVersion(id string, ii *InstallInfo, rdx redux.Readable) (string, bool) {
+	return rdx.GetLastVal(data.HoldProperty, data.AppOsLangCode(id, ii.OperatingSystem, ii.LangCode))
+}
@@ -43,6 +43,15 @@ func InstallHandler(u *url.URL) error {
 		force:                  q.Has(vangogh_integration.UrlForceParameter),
 	}
 
+	var err error
+	if ii.concurrency, err = parseConcurrency(q); err != nil {
+		return err
+	}
+
+	if ii.Library, err = parseLibrary(q.Get(data.UrlLibraryParameter)); err != nil {
+		return err
+	}
+
 	if q.Has(vangogh_integration.UrlSteamParameter) {
 		ii.Origin = data.SteamOrigin
 	}
@@ -63,7 +72,7 @@ func Install(id string, ii *InstallInfo) error {
 	ia := nod.Begin("installing %s...", id)
 	defer ia.Done()
 
-	rdx, err := redux.NewWriter(data.AbsReduxDir(), data.AllProperties()...)
+	rdx, err := newReduxWriter(data.AllProperties()...)
 	if err != nil {
 		return err
 	}
@@ -86,7 +95,11 @@ func Install(id string, ii *InstallInfo) error {
 		}
 	}
 
-	if err = BackupMetadata(); err != nil {
+	if _, err = data.AbsLibraryRoot(ii.Library, rdx); err != nil {
+		return err
+	}
+
+	if err = ii.backupMetadata(); err != nil {
 		return err
 	}
 
@@ -95,64 +108,112 @@ func Install(id string, ii *InstallInfo) error {
 		return err
 	}
 
-	if err = Download(id, ii, originData); err != nil {
+	pl, err := lockProduct(id, ii, "installing")
+	if err != nil {
 		return err
 	}
+	defer pl.release()
 
-	if err = Validate(id, ii); err != nil {
-		return err
+	// another theo process might have installed the product while waiting for the lock
+	if !ii.force {
+		if rdx, err = rdx.RefreshWriter(); err != nil {
+			return err
+		}
+		var ok bool
+		if ok, err = hasInstallInfo(id, ii, rdx); ok && err == nil {
+			ia.EndWithResult("already installed")
+			return nil
+		} else if err != nil {
+			return err
+		}
 	}
 
-	if err = osPreInstallActions(id, ii, rdx); err != nil {
+	it, err := beginInstallTransaction(id, ii, rdx)
+	if err != nil {
 		return err
 	}
 
-	if err = originInstallMainProduct(id, ii, originData, rdx); err != nil {
+	if err = installSteps(id, ii, originData, rdx, it); err != nil {
+		return it.rollback(err)
+	}
+
+	if err = it.commit(); err != nil {
 		return err
 	}
 
-	if !ii.NoDlcs {
-		if err = originInstallDlcs(id, ii, originData, rdx); err != nil {
+	if !ii.KeepDownloads {
+		if err = RemoveDownloads(id, ii, rdx); err != nil {
 			return err
 		}
 	}
 
-	if err = originAddSteamShortcut(id, id, ii, originData, rdx); err != nil {
+	return nil
+}
+
+func installSteps(id string, ii *InstallInfo, originData *data.OriginData, rdx redux.Writeable, it *installTransaction) error {
+
+	if err := Download(id, ii, originData); err != nil {
+		return err
+	}
+
+	if err := Validate(id, ii); err != nil {
 		return err
 	}
 
-	if err = originPostInstall(id, ii, originData, rdx); err != nil {
+	if err := it.stagePrevious(); err != nil {
 		return err
 	}
 
-	if !ii.KeepDownloads {
-		if err = RemoveDownloads(id, ii, rdx); err != nil {
+	if err := osPreInstallActions(id, ii, rdx); err != nil {
+		return err
+	}
+
+	if err := it.do(stepInstallMainProduct,
+		func() error { return originInstallMainProduct(id, ii, originData, rdx) }); err != nil {
+		return err
+	}
+
+	if !ii.NoDlcs {
+		if err := it.do(stepInstallDlcs,
+			func() error { return originInstallDlcs(id, ii, originData, rdx) }); err != nil {
 			return err
 		}
 	}
 
-	if err = originPinInstallInfo(id, ii, originData, rdx); err != nil {
+	if err := it.do(stepAddSteamShortcut,
+		func() error { return originAddSteamShortcut(id, id, ii, originData, rdx) }); err != nil {
+		return err
+	}
+
+	if err := it.do(stepPostInstallActions,
+		func() error { return originPostInstall(id, ii, originData, rdx) }); err != nil {
+		return err
+	}
+
+	if err := it.do(stepPinInstallInfo,
+		func() error { return originPinInstallInfo(id, ii, originData, rdx) }); err != nil {
 		return err
 	}
 
 	if !ii.NoPresentLaunchOptions {
-		if err = PresetLaunchOptions(id, ii, rdx); err != nil {
+		if err := it.do(stepPresetLaunchOptions,
+			func() error { return PresetLaunchOptions(id, ii, rdx) }); err != nil {
 			return err
 		}
 	}
 
 	idInstalledDate := map[string][]string{id: {time.Now().UTC().Format(time.RFC3339)}}
-	if err = rdx.BatchReplaceValues(data.InstallDateProperty, idInstalledDate); err != nil {
-		return err
-	}
-
-	return nil
+	return it.do(stepSetInstallDate,
+		func() error { return rdx.BatchReplaceValues(data.InstallDateProperty, idInstalledDate) })
 }
 
 func originPinInstallInfo(id string, ii *InstallInfo, originData *data.OriginData, rdx redux.Writeable) error {
 
 	switch ii.Origin {
 	case data.EpicGamesOrigin:
+		if err := egsPinInstalledManifest(id, ii.OperatingSystem); err != nil {
+			return err
+		}
 		// don't pin EGS DLC install info, as it's already tracked in the pinned main game item install info
 		if len(originData.CatalogItem.MainGameItemList) > 0 {
 			return nil
@@ -292,7 +353,7 @@ func osPreInstallActions(id string, ii *InstallInfo, rdx redux.Readable) error {
 		case vangogh_integration.MacOS:
 			fallthrough
 		case vangogh_integration.Linux:
-			return prefixInit(id, ii.Origin, rdx, ii.verbose)
+			return prefixInit(id, ii, rdx, ii.verbose)
 		default:
 			return nil
 		}
@@ -310,7 +371,10 @@ func originOsInstalledPath(id string, ii *InstallInfo, rdx redux.Readable) (stri
 			return "", err
 		}
 
-		installedAppsDir := data.Pwd.AbsDirPath(data.InstalledApps)
+		installedAppsDir, err := data.AbsLibraryDir(ii.Library, data.InstalledApps, rdx)
+		if err != nil {
+			return "", err
+		}
 
 		osLangInstalledAppsDir := filepath.Join(installedAppsDir, data.OsLangCode(ii.OperatingSystem, ii.LangCode))
 
@@ -332,13 +396,16 @@ func originOsInstalledPath(id string, ii *InstallInfo, rdx redux.Readable) (stri
 
 		return filepath.Join(osLangInstalledAppsDir, appInstalledPath), nil
 	case data.SteamOrigin:
-		if steamAppInstallDir, err := data.AbsSteamAppInstallDir(id, ii.OperatingSystem, rdx); err == nil {
+		if steamAppInstallDir, err := data.AbsSteamAppInstallDir(id, ii.OperatingSystem, ii.Library, rdx); err == nil {
 			return steamAppInstallDir, nil
 		} else {
 			return "", err
 		}
 	case data.EpicGamesOrigin:
-		egsAppsDir := data.Pwd.AbsDirPath(data.EgsApps)
+		egsAppsDir, err := data.AbsLibraryDir(ii.Library, data.EgsApps, rdx)
+		if err != nil {
+			return "", err
+		}
 
 		osEgsAppsDir := filepath.Join(egsAppsDir, ii.OperatingSystem.String())
 
@@ -39,8 +39,29 @@ type InstallInfo struct {
 	NoSteamShortcut        bool                                `json:"no-steam-shortcut"`
 	NoPresentLaunchOptions bool                                `json:"no-preset-launch-options"`
 	Env                    []string                            `json:"env"`
+	Library                string                              `json:"library,omitempty"`
 	verbose                bool                                // won't be serialized
 	force                  bool                                // won't be serialized
+	concurrency            int                                 // won't be serialized
+	latestVersion          string                              // won't be serialized
+	metadataBackedUp       bool                                // won't be serialized
+}
+
+// backupMetadata backs up metadata once per operation, so that
+// DLCs installed or updated with the main product are not backed up again
+func (ii *InstallInfo) backupMetadata() error {
+
+	if ii.metadataBackedUp {
+		return nil
+	}
+
+	if err := BackupMetadata(); err != nil {
+		return err
+	}
+
+	ii.metadataBackedUp = true
+
+	return nil
 }
 
 func (ii *InstallInfo) reduceOriginData(id string, originData *data.OriginData) error {
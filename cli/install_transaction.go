@@ -0,0 +1,769 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json/v2"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/arelate/southern_light/egs_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/pathways"
+	"github.com/boggydigital/redux"
+)
+
+const (
+	transactionPreviousDir       = "previous"
+	transactionInventoryFilename = "inventory.json"
+	transactionInstallInfoFile   = "install-info.json"
+	transactionJournalFilename   = "steps.journal"
+	transactionRequestFilename   = "request.json"
+	transactionModesFilename     = "modes.json"
+	// nested transactions (e.g. DLCs) install info and manifests staged by the outer one
+	transactionNestedDir = "nested"
+	// EGS manifest pinned by the previous installation
+	transactionInstalledManifestFilename = "installed" + egs_integration.ManifestExt
+	// delta updates stage replaced files instead of the whole installation
+	transactionDeltaDir           = "delta"
+	transactionDeltaAddedFilename = "delta-added.json"
+)
+
+// install steps recorded in the transaction journal, interrupted
+// transactions are recovered by undoing the steps that were started
+const (
+	stepStagePrevious        = "stage previous installation"
+	stepSharePrevious        = "share previous installation staged by outer transaction"
+	stepInstallMainProduct   = "install main product"
+	stepInstallDlcs          = "install DLCs"
+	stepPlaceChangedFiles    = "place changed files"
+	stepAssembleChangedFiles = "assemble changed files"
+	stepUpdateDlcs           = "update DLCs"
+	stepAddSteamShortcut     = "add Steam shortcut"
+	stepPostInstallActions   = "post-install actions"
+	stepPinInstallInfo       = "pin install info"
+	stepPresetLaunchOptions  = "preset launch options"
+	stepSetInstallDate       = "set install date"
+)
+
+const (
+	journalStarted   = "started"
+	journalCompleted = "completed"
+)
+
+var (
+	stagedPathsMtx sync.Mutex
+	// installation directories staged by the outer transactions
+	stagedPaths = make(map[string]*installTransaction)
+)
+
+// installTransaction records completed install steps in a staging directory
+// under Temp and undoes them in reverse order when a later step fails.
+// Previous installation is moved to the staging directory for the duration
+// of the transaction and is restored on rollback
+type installTransaction struct {
+	id                string
+	ii                *InstallInfo
+	rdx               redux.Writeable
+	absStagingDir     string
+	absInstalledPath  string
+	absInventoryPath  string
+	stagedInstalled   bool
+	nested            bool
+	installInfoLines  []string
+	hadInstallInfo    bool
+	undo              []func() error
+	journal           *os.File
+	freshInstallation bool
+	// files installed by theo in the previous installation,
+	// other files are kept on commit
+	previousFiles map[string]any
+}
+
+func beginInstallTransaction(id string, ii *InstallInfo, rdx redux.Writeable) (*installTransaction, error) {
+
+	// staging in the library keeps previous installation on the same volume
+	libraryTempDir, err := data.AbsLibraryDir(ii.Library, data.Temp, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	absStagingDir := filepath.Join(libraryTempDir, string(data.Transactions), productLockName(id, ii))
+
+	// an interrupted transaction left previous installation in the staging directory
+	if _, err = os.Stat(absStagingDir); err == nil {
+		if err = recoverInstallTransaction(id, ii, rdx, absStagingDir); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = os.MkdirAll(absStagingDir, pathways.PermUrwGrwOr); err != nil {
+		return nil, err
+	}
+
+	// recovery undoes the steps with the install info of the interrupted transaction
+	if err = writeTransactionRequest(absStagingDir, ii); err != nil {
+		return nil, err
+	}
+
+	it := &installTransaction{
+		id:            id,
+		ii:            ii,
+		rdx:           rdx,
+		absStagingDir: absStagingDir,
+	}
+
+	if it.absInstalledPath, err = originOsInstalledPath(id, ii, rdx); err != nil {
+		return nil, err
+	}
+
+	if ii.Origin == data.VangoghOrigin {
+		if it.absInventoryPath, err = data.AbsInventoryFilename(id, ii.LangCode, ii.OperatingSystem, rdx); err != nil {
+			return nil, err
+		}
+	}
+
+	if it.installInfoLines, it.hadInstallInfo = rdx.GetAllValues(data.InstallInfoProperty, id); it.hadInstallInfo {
+		if err = writeTransactionInstallInfo(absStagingDir, it.installInfoLines); err != nil {
+			return nil, err
+		}
+	}
+
+	if ii.Origin == data.EpicGamesOrigin {
+		if err = egsStageInstalledManifest(absStagingDir, id, ii.OperatingSystem); err != nil {
+			return nil, err
+		}
+	}
+
+	it.freshInstallation = !it.hadInstallInfo
+
+	if it.journal, err = os.OpenFile(filepath.Join(absStagingDir, transactionJournalFilename),
+		os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644); err != nil {
+		return nil, err
+	}
+
+	return it, nil
+}
+
+// stagePrevious moves existing installation out of the way, so that
+// it can be restored if the new installation fails
+func (it *installTransaction) stagePrevious() error {
+
+	switch it.ii.Origin {
+	case data.SteamOrigin:
+		// SteamCMD updates installation in place
+		return nil
+	default:
+		// do nothing
+	}
+
+	stagedPathsMtx.Lock()
+	defer stagedPathsMtx.Unlock()
+
+	// nested installations (e.g. DLCs) share the installation directory
+	// that was already staged by the outer transaction
+	if outer, ok := stagedPaths[it.absInstalledPath]; ok {
+		it.nested = true
+		if err := outer.stageNested(it); err != nil {
+			return err
+		}
+		return it.record(journalCompleted, stepSharePrevious)
+	}
+
+	it.undo = append(it.undo, it.restorePrevious)
+
+	if err := it.record(journalStarted, stepStagePrevious); err != nil {
+		return err
+	}
+
+	if err := it.readPreviousFiles(); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(it.absInstalledPath); err == nil {
+		if err = os.Rename(it.absInstalledPath, filepath.Join(it.absStagingDir, transactionPreviousDir)); err != nil {
+			return err
+		}
+	}
+
+	// fresh installations are shared with nested installations as well
+	stagedPaths[it.absInstalledPath] = it
+	it.stagedInstalled = true
+
+	if it.absInventoryPath != "" {
+		if _, err := os.Stat(it.absInventoryPath); err == nil {
+			if err = moveAll(it.absInventoryPath, filepath.Join(it.absStagingDir, transactionInventoryFilename)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return it.record(journalCompleted, stepStagePrevious)
+}
+
+// shareInstalledPath marks installation directory as staged by this transaction
+// without moving it, so that nested installations (e.g. DLCs) installed during
+// an in-place update won't stage it either
+func (it *installTransaction) shareInstalledPath() {
+
+	stagedPathsMtx.Lock()
+	defer stagedPathsMtx.Unlock()
+
+	if _, ok := stagedPaths[it.absInstalledPath]; ok {
+		return
+	}
+
+	stagedPaths[it.absInstalledPath] = it
+	it.stagedInstalled = true
+}
+
+// shareOuter stages install info of the transaction with the outer transaction
+// that staged the installation directory, e.g. for DLCs updated in place
+func (it *installTransaction) shareOuter() error {
+
+	stagedPathsMtx.Lock()
+	defer stagedPathsMtx.Unlock()
+
+	if outer, ok := stagedPaths[it.absInstalledPath]; ok && outer != it {
+		return outer.stageNested(it)
+	}
+
+	return nil
+}
+
+// stageNested keeps install info and EGS manifest of the nested transaction,
+// as nested transactions commit before the outer transaction completes
+func (it *installTransaction) stageNested(nested *installTransaction) error {
+
+	absNestedDir := filepath.Join(it.absStagingDir, transactionNestedDir, nested.id)
+
+	// the state before the first nested installation is kept
+	if _, err := os.Stat(absNestedDir); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(absNestedDir, pathways.PermUrwGrwOr); err != nil {
+		return err
+	}
+
+	if nested.hadInstallInfo {
+		if err := writeTransactionInstallInfo(absNestedDir, nested.installInfoLines); err != nil {
+			return err
+		}
+	}
+
+	if nested.ii.Origin == data.EpicGamesOrigin {
+		return egsStageInstalledManifest(absNestedDir, nested.id, nested.ii.OperatingSystem)
+	}
+
+	return nil
+}
+
+// do runs a step, registering and journaling it before the step runs,
+// so that partially completed step would be undone as well
+func (it *installTransaction) do(step string, doFunc func() error) error {
+
+	if undoFunc := it.undoFunc(step); undoFunc != nil {
+		it.undo = append(it.undo, undoFunc)
+	}
+
+	if err := it.record(journalStarted, step); err != nil {
+		return err
+	}
+
+	if step == stepPostInstallActions {
+		if err := it.stageModes(); err != nil {
+			return err
+		}
+	}
+
+	if err := doFunc(); err != nil {
+		return err
+	}
+
+	return it.record(journalCompleted, step)
+}
+
+// undoFunc returns the function that undoes a step, used both for
+// rollback and recovery of interrupted transactions
+func (it *installTransaction) undoFunc(step string) func() error {
+	switch step {
+	case stepStagePrevious, stepPlaceChangedFiles, stepAssembleChangedFiles:
+		return it.restorePrevious
+	case stepInstallMainProduct:
+		return it.removeInstalledFiles
+	case stepInstallDlcs, stepUpdateDlcs:
+		return it.restoreNested
+	case stepPostInstallActions:
+		return it.restoreModes
+	case stepAddSteamShortcut:
+		return it.removeSteamShortcut
+	case stepPinInstallInfo:
+		return it.restoreInstallInfo
+	case stepPresetLaunchOptions:
+		return it.resetLaunchOptions
+	default:
+		return nil
+	}
+}
+
+func (it *installTransaction) record(status, step string) error {
+	_, err := io.WriteString(it.journal, status+": "+step+"\n")
+	return err
+}
+
+func (it *installTransaction) rollback(cause error) error {
+
+	ita := nod.Begin("rolling back installation of %s...", it.id)
+	defer ita.Done()
+
+	errs := []error{cause}
+
+	for ii := len(it.undo) - 1; ii >= 0; ii-- {
+		if err := it.undo[ii](); err != nil {
+			ita.Error(err)
+			errs = append(errs, err)
+		}
+	}
+
+	if err := it.journal.Close(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 1 {
+		errs = append(errs, errors.New("rollback incomplete, staged files preserved in "+it.absStagingDir))
+		return errors.Join(errs...)
+	}
+
+	it.release()
+
+	if err := os.RemoveAll(it.absStagingDir); err != nil {
+		return errors.Join(cause, err)
+	}
+
+	ita.EndWithResult("restored previous state")
+
+	return cause
+}
+
+func (it *installTransaction) commit() error {
+
+	if err := it.journal.Close(); err != nil {
+		return err
+	}
+
+	if err := it.keepUserFiles(); err != nil {
+		return err
+	}
+
+	it.release()
+
+	return os.RemoveAll(it.absStagingDir)
+}
+
+func (it *installTransaction) readPreviousFiles() error {
+
+	var relFiles []string
+	var err error
+
+	switch it.ii.Origin {
+	case data.VangoghOrigin:
+		if relFiles, err = readInventory(it.id, it.ii, it.rdx); err != nil {
+			return err
+		}
+	case data.EpicGamesOrigin:
+		var installedManifest *egs_integration.Manifest
+		if installedManifest, err = egsReadInstalledManifest(it.id, it.ii.OperatingSystem); err != nil {
+			return err
+		} else if installedManifest != nil {
+			for _, file := range installedManifest.FileList.List {
+				relFiles = append(relFiles, file.Filename)
+			}
+		}
+	default:
+		// do nothing
+	}
+
+	it.previousFiles = make(map[string]any, len(relFiles))
+	for _, relFile := range relFiles {
+		it.previousFiles[relFile] = nil
+	}
+
+	return nil
+}
+
+// keepUserFiles moves files that were not installed by theo (saves, configs, mods)
+// from the staged previous installation to the new one. Without inventory of the
+// previous installation all files that are missing in the new installation are kept
+func (it *installTransaction) keepUserFiles() error {
+
+	absPreviousDir := filepath.Join(it.absStagingDir, transactionPreviousDir)
+	if _, err := os.Stat(absPreviousDir); os.IsNotExist(err) {
+		return nil
+	}
+
+	relFiles, err := relWalkDir(absPreviousDir)
+	if err != nil {
+		return err
+	}
+
+	for _, relFile := range relFiles {
+
+		if _, ok := it.previousFiles[relFile]; ok {
+			continue
+		}
+
+		absInstalledFile := filepath.Join(it.absInstalledPath, relFile)
+		if _, err = os.Lstat(absInstalledFile); err == nil {
+			continue
+		}
+
+		if err = placeFile(filepath.Join(absPreviousDir, relFile), absInstalledFile); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (it *installTransaction) release() {
+	if it.stagedInstalled {
+		stagedPathsMtx.Lock()
+		delete(stagedPaths, it.absInstalledPath)
+		stagedPathsMtx.Unlock()
+	}
+}
+
+func (it *installTransaction) removeInstalledFiles() error {
+
+	if it.ii.Origin == data.SteamOrigin {
+		return nil
+	}
+
+	// nested installations files are removed with the outer transaction
+	if it.nested {
+		return nil
+	}
+
+	if it.absInventoryPath != "" {
+		if _, err := os.Stat(it.absInventoryPath); err == nil {
+			if err = removeInventoriedFiles(it.id, it.ii, it.rdx); err != nil {
+				return err
+			}
+			if err = os.Remove(it.absInventoryPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := os.Stat(it.absInstalledPath); err == nil {
+		return os.RemoveAll(it.absInstalledPath)
+	}
+
+	return nil
+}
+
+func (it *installTransaction) restorePrevious() error {
+	if it.nested {
+		return nil
+	}
+	return restorePreviousInstallation(it.absStagingDir, it.absInstalledPath, it.absInventoryPath)
+}
+
+func (it *installTransaction) restoreInstallInfo() error {
+
+	if it.ii.Origin == data.EpicGamesOrigin {
+		if err := egsRestoreInstalledManifest(it.absStagingDir, it.id, it.ii.OperatingSystem); err != nil {
+			return err
+		}
+	}
+
+	switch it.hadInstallInfo {
+	case true:
+		return it.rdx.ReplaceValues(data.InstallInfoProperty, it.id, it.installInfoLines...)
+	default:
+		if it.rdx.HasKey(data.InstallInfoProperty, it.id) {
+			return it.rdx.CutKeys(data.InstallInfoProperty, it.id)
+		}
+		return nil
+	}
+}
+
+// restoreNested restores install info and EGS manifests of the nested transactions
+// (e.g. DLCs), their files are restored or removed with the outer installation
+func (it *installTransaction) restoreNested() error {
+
+	absNestedDir := filepath.Join(it.absStagingDir, transactionNestedDir)
+
+	entries, err := os.ReadDir(absNestedDir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+
+		nested := &installTransaction{
+			id:            entry.Name(),
+			ii:            it.ii,
+			rdx:           it.rdx,
+			absStagingDir: filepath.Join(absNestedDir, entry.Name()),
+		}
+
+		if nested.installInfoLines, nested.hadInstallInfo, err = readTransactionInstallInfo(nested.absStagingDir); err != nil {
+			return err
+		}
+
+		if err = nested.restoreInstallInfo(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// stageModes keeps installed files modes, that post-install actions might change
+func (it *installTransaction) stageModes() error {
+
+	if _, err := os.Stat(it.absInstalledPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	relFiles, err := relWalkDir(it.absInstalledPath)
+	if err != nil {
+		return err
+	}
+
+	modes := make(map[string]os.FileMode, len(relFiles))
+	for _, relFile := range relFiles {
+		if fi, err := os.Lstat(filepath.Join(it.absInstalledPath, relFile)); err == nil {
+			modes[relFile] = fi.Mode().Perm()
+		}
+	}
+
+	modesFile, err := os.Create(filepath.Join(it.absStagingDir, transactionModesFilename))
+	if err != nil {
+		return err
+	}
+	defer modesFile.Close()
+
+	return json.MarshalWrite(modesFile, modes)
+}
+
+func (it *installTransaction) restoreModes() error {
+
+	modesFile, err := os.Open(filepath.Join(it.absStagingDir, transactionModesFilename))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer modesFile.Close()
+
+	var modes map[string]os.FileMode
+	if err = json.UnmarshalRead(modesFile, &modes); err != nil {
+		return err
+	}
+
+	for relFile, mode := range modes {
+		absFile := filepath.Join(it.absInstalledPath, relFile)
+		if fi, err := os.Lstat(absFile); err == nil && fi.Mode().Perm() != mode {
+			if err = os.Chmod(absFile, mode); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (it *installTransaction) removeSteamShortcut() error {
+	// keep existing shortcut for reinstalls and updates
+	if !it.freshInstallation {
+		return nil
+	}
+	return removeSteamShortcut(it.id, it.rdx)
+}
+
+func (it *installTransaction) resetLaunchOptions() error {
+	// keep existing launch options for reinstalls and updates
+	if !it.freshInstallation {
+		return nil
+	}
+	return LaunchOptions(it.id, it.ii, new(execTask), true)
+}
+
+func restorePreviousInstallation(absStagingDir, absInstalledPath, absInventoryPath string) error {
+
+	if err := restoreDeltaFiles(absStagingDir, absInstalledPath); err != nil {
+		return err
+	}
+
+	absPreviousDir := filepath.Join(absStagingDir, transactionPreviousDir)
+	if _, err := os.Stat(absPreviousDir); err == nil {
+		if _, err = os.Stat(absInstalledPath); err == nil {
+			if err = os.RemoveAll(absInstalledPath); err != nil {
+				return err
+			}
+		}
+		if err = os.MkdirAll(filepath.Dir(absInstalledPath), pathways.PermUrwGrwOr); err != nil {
+			return err
+		}
+		if err = os.Rename(absPreviousDir, absInstalledPath); err != nil {
+			return err
+		}
+	}
+
+	absStagedInventory := filepath.Join(absStagingDir, transactionInventoryFilename)
+	if _, err := os.Stat(absStagedInventory); err == nil && absInventoryPath != "" {
+		if err = moveAll(absStagedInventory, absInventoryPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeTransactionInstallInfo(absStagingDir string, installInfoLines []string) error {
+
+	iiFile, err := os.Create(filepath.Join(absStagingDir, transactionInstallInfoFile))
+	if err != nil {
+		return err
+	}
+	defer iiFile.Close()
+
+	return json.MarshalWrite(iiFile, installInfoLines)
+}
+
+// recoverInstallTransaction undoes the steps journaled by a transaction
+// that was interrupted before it could commit or roll back
+func recoverInstallTransaction(id string, ii *InstallInfo, rdx redux.Writeable, absStagingDir string) error {
+
+	rita := nod.Begin(" recovering interrupted installation of %s...", id)
+	defer rita.Done()
+
+	journal, err := readTransactionJournal(absStagingDir)
+	if err != nil {
+		return err
+	}
+
+	// interrupted transaction might have been requested with another install info,
+	// e.g. a different library
+	if journaledIi, err := readTransactionRequest(absStagingDir); err != nil {
+		return err
+	} else if journaledIi != nil {
+		ii = journaledIi
+	}
+
+	it := &installTransaction{
+		id:            id,
+		ii:            ii,
+		rdx:           rdx,
+		absStagingDir: absStagingDir,
+		nested:        slices.Contains(journal[journalCompleted], stepSharePrevious),
+	}
+
+	if it.absInstalledPath, err = originOsInstalledPath(id, ii, rdx); err != nil {
+		return err
+	}
+
+	if ii.Origin == data.VangoghOrigin {
+		if it.absInventoryPath, err = data.AbsInventoryFilename(id, ii.LangCode, ii.OperatingSystem, rdx); err != nil {
+			return err
+		}
+	}
+
+	if it.installInfoLines, it.hadInstallInfo, err = readTransactionInstallInfo(absStagingDir); err != nil {
+		return err
+	}
+
+	it.freshInstallation = !it.hadInstallInfo
+
+	startedSteps := journal[journalStarted]
+
+	for ii := len(startedSteps) - 1; ii >= 0; ii-- {
+		if undoFunc := it.undoFunc(startedSteps[ii]); undoFunc != nil {
+			if err = undoFunc(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return os.RemoveAll(absStagingDir)
+}
+
+// readTransactionJournal returns journaled steps by status
+func readTransactionJournal(absStagingDir string) (map[string][]string, error) {
+
+	journal := make(map[string][]string)
+
+	journalFile, err := os.Open(filepath.Join(absStagingDir, transactionJournalFilename))
+	if os.IsNotExist(err) {
+		return journal, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer journalFile.Close()
+
+	scanner := bufio.NewScanner(journalFile)
+	for scanner.Scan() {
+		if status, step, ok := strings.Cut(scanner.Text(), ": "); ok {
+			journal[status] = append(journal[status], step)
+		}
+	}
+
+	return journal, scanner.Err()
+}
+
+func writeTransactionRequest(absStagingDir string, ii *InstallInfo) error {
+
+	requestFile, err := os.Create(filepath.Join(absStagingDir, transactionRequestFilename))
+	if err != nil {
+		return err
+	}
+	defer requestFile.Close()
+
+	return json.MarshalWrite(requestFile, ii)
+}
+
+func readTransactionRequest(absStagingDir string) (*InstallInfo, error) {
+
+	requestFile, err := os.Open(filepath.Join(absStagingDir, transactionRequestFilename))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer requestFile.Close()
+
+	var ii InstallInfo
+	if err = json.UnmarshalRead(requestFile, &ii); err != nil {
+		return nil, err
+	}
+
+	return &ii, nil
+}
+
+func readTransactionInstallInfo(absStagingDir string) ([]string, bool, error) {
+
+	iiFile, err := os.Open(filepath.Join(absStagingDir, transactionInstallInfoFile))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	defer iiFile.Close()
+
+	var installInfoLines []string
+	if err = json.UnmarshalRead(iiFile, &installInfoLines); err != nil {
+		return nil, false, err
+	}
+
+	return installInfoLines, true, nil
+}
@@ -1,7 +1,10 @@
 package cli
 
 import (
+	"crypto/md5"
 	"encoding/json/v2"
+	"fmt"
+	"io"
 	"maps"
 	"os"
 	"path/filepath"
@@ -57,9 +60,56 @@ func getInventory(operatingSystem vangogh_integration.OperatingSystem, dls vango
 	return slices.Sorted(maps.Keys(filesMap)), nil
 }
 
+// inventoryEntry is an installed file relative path, recorded
+// with the size and checksum of the placed file when available
+type inventoryEntry struct {
+	Path string `json:"path"`
+	Size int64  `json:"size,omitempty"`
+	Md5  string `json:"md5,omitempty"`
+}
+
+// UnmarshalJSON supports inventories that recorded relative paths only
+func (ie *inventoryEntry) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		return json.Unmarshal(b, &ie.Path)
+	}
+	type plainInventoryEntry inventoryEntry
+	return json.Unmarshal(b, (*plainInventoryEntry)(ie))
+}
+
+func newInventoryEntries(relFiles ...string) []inventoryEntry {
+	entries := make([]inventoryEntry, 0, len(relFiles))
+	for _, relFile := range relFiles {
+		entries = append(entries, inventoryEntry{Path: relFile})
+	}
+	return entries
+}
+
+func inventoryPaths(entries []inventoryEntry) []string {
+	relFiles := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		relFiles = append(relFiles, entry.Path)
+	}
+	return relFiles
+}
+
 func readInventory(id string, ii *InstallInfo, rdx redux.Readable) ([]string, error) {
 
-	absInventoryFilename, err := data.AbsInventoryFilename(id, ii.LangCode, ii.OperatingSystem, rdx)
+	entries, err := readInventoryEntries(id, ii.LangCode, ii.OperatingSystem, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	if entries == nil {
+		return nil, nil
+	}
+
+	return inventoryPaths(entries), nil
+}
+
+func readInventoryEntries(id, langCode string, operatingSystem vangogh_integration.OperatingSystem, rdx redux.Readable) ([]inventoryEntry, error) {
+
+	absInventoryFilename, err := data.AbsInventoryFilename(id, langCode, operatingSystem, rdx)
 	if err != nil {
 		return nil, err
 	}
@@ -72,56 +122,133 @@ func readInventory(id string, ii *InstallInfo, rdx redux.Readable) ([]string, er
 	if err != nil {
 		return nil, err
 	}
+	defer inventoryFile.Close()
 
-	var relFiles []string
-	if err = json.UnmarshalRead(inventoryFile, &relFiles); err != nil {
+	var entries []inventoryEntry
+	if err = json.UnmarshalRead(inventoryFile, &entries); err != nil {
 		return nil, err
 	}
 
-	return relFiles, nil
+	return entries, nil
 }
 
+// appendInventory adds relative paths to the inventory. Files that were already
+// inventoried are replaced, as they've been overwritten (e.g. by DLC files)
 func appendInventory(id, langCode string, operatingSystem vangogh_integration.OperatingSystem, rdx redux.Readable, inventory ...string) error {
 
+	existingEntries, err := readInventoryEntries(id, langCode, operatingSystem, rdx)
+	if err != nil {
+		return err
+	}
+
+	appendedFiles := make(map[string]any, len(inventory))
+	for _, relFile := range inventory {
+		appendedFiles[relFile] = nil
+	}
+
+	existingEntries = slices.DeleteFunc(existingEntries, func(entry inventoryEntry) bool {
+		_, ok := appendedFiles[entry.Path]
+		return ok
+	})
+
+	return writeInventory(id, langCode, operatingSystem, rdx, append(existingEntries, newInventoryEntries(inventory...)...)...)
+}
+
+func writeInventory(id, langCode string, operatingSystem vangogh_integration.OperatingSystem, rdx redux.Readable, entries ...inventoryEntry) error {
+
 	absInventoryFilename, err := data.AbsInventoryFilename(id, langCode, operatingSystem, rdx)
 	if err != nil {
 		return err
 	}
 
-	absInventoryDir, _ := filepath.Split(absInventoryFilename)
+	if err = os.MkdirAll(filepath.Dir(absInventoryFilename), pathways.PermUrwGrwOr); err != nil {
+		return err
+	}
 
-	pathDir, _ := filepath.Split(absInventoryDir)
-	if _, err = os.Stat(pathDir); os.IsNotExist(err) {
-		if err = os.MkdirAll(pathDir, pathways.PermUrwGrwOr); err != nil {
-			return err
-		}
+	inventoryFile, err := os.Create(absInventoryFilename)
+	if err != nil {
+		return err
+	}
+	defer inventoryFile.Close()
+
+	return json.MarshalWrite(inventoryFile, entries)
+}
+
+// checksumInventory records size and checksum of the placed files
+// that have been inventoried without them
+func checksumInventory(id string, ii *InstallInfo, rdx redux.Readable) error {
+
+	cia := nod.NewProgress(" computing checksums of the installed files...")
+	defer cia.Done()
+
+	absInstalledPath, err := originOsInstalledPath(id, ii, rdx)
+	if err != nil {
+		return err
 	}
 
-	var existingInventory []string
+	entries, err := readInventoryEntries(id, ii.LangCode, ii.OperatingSystem, rdx)
+	if err != nil {
+		return err
+	}
 
-	if _, err = os.Stat(absInventoryFilename); err == nil {
-		var inventoryFile *os.File
-		inventoryFile, err = os.Open(absInventoryFilename)
-		if err != nil {
-			return err
-		}
+	cia.TotalInt(len(entries))
 
-		err = json.UnmarshalRead(inventoryFile, &existingInventory)
-		inventoryFile.Close()
-		if err != nil {
-			return err
+	for ie := range entries {
+
+		if entries[ie].Md5 == "" {
+			if err = entries[ie].checksum(absInstalledPath); err != nil {
+				return err
+			}
 		}
+
+		cia.Increment()
+	}
+
+	return writeInventory(id, ii.LangCode, ii.OperatingSystem, rdx, entries...)
+}
+
+// checksum sets size and checksum of the regular files, other
+// file types (e.g. symlinks) are only verified to be present
+func (ie *inventoryEntry) checksum(absInstalledPath string) error {
+
+	absPath := filepath.Join(absInstalledPath, ie.Path)
+
+	stat, err := os.Lstat(absPath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
 	}
 
-	existingInventory = append(existingInventory, inventory...)
+	if !stat.Mode().IsRegular() {
+		return nil
+	}
 
-	inventoryFile, err := os.Create(absInventoryFilename)
+	md5Sum, err := fileMd5(absPath)
 	if err != nil {
 		return err
 	}
-	defer inventoryFile.Close()
 
-	return json.MarshalWrite(inventoryFile, existingInventory)
+	ie.Size = stat.Size()
+	ie.Md5 = md5Sum
+
+	return nil
+}
+
+func fileMd5(absPath string) (string, error) {
+
+	file, err := os.Open(absPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := md5.New()
+	if _, err = io.Copy(h, file); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
 }
 
 func removeInventoriedFiles(id string, ii *InstallInfo, rdx redux.Readable) error {
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+const (
+	preLaunchHook = "pre-launch"
+	postExitHook  = "post-exit"
+)
+
+const (
+	hookIdEnv         = "THEO_ID"
+	hookTitleEnv      = "THEO_TITLE"
+	hookOriginEnv     = "THEO_ORIGIN"
+	hookOsEnv         = "THEO_OS"
+	hookLangCodeEnv   = "THEO_LANG_CODE"
+	hookInstallDirEnv = "THEO_INSTALL_DIR"
+	hookPrefixEnv     = "THEO_PREFIX"
+	hookExitStatusEnv = "THEO_EXIT_STATUS"
+)
+
+// runLaunchHooks runs product hooks as system shell commands with product details set in the
+// environment. Pre-launch hooks stop at the first failure, as the launch needs to be
+// aborted. All post-exit hooks are run, e.g. saves sync shouldn't depend on other hooks
+func runLaunchHooks(hook string, hooks []string, id string, ii *InstallInfo, et *execTask, runErr error, rdx redux.Readable) error {
+
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	rlha := nod.Begin(" running %s hooks...", hook)
+	defer rlha.Done()
+
+	absInstalledPath, err := originOsInstalledPath(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	env := append(os.Environ(),
+		hookEventEnv+"="+hook,
+		hookIdEnv+"="+id,
+		hookOriginEnv+"="+ii.Origin.String(),
+		hookOsEnv+"="+ii.OperatingSystem.String(),
+		hookLangCodeEnv+"="+ii.LangCode,
+		hookInstallDirEnv+"="+absInstalledPath,
+		hookPrefixEnv+"="+et.prefix)
+
+	if title, err := data.GetTitleProperty(id, rdx); err == nil {
+		env = append(env, hookTitleEnv+"="+title)
+	}
+
+	if hook == postExitHook {
+		env = append(env, hookExitStatusEnv+"="+runExitStatus(runErr))
+	}
+
+	var hooksErr error
+
+	for _, command := range hooks {
+
+		cmd := shellCommand(command)
+		cmd.Env = env
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		if err = cmd.Run(); err != nil {
+			err = fmt.Errorf("%s hook %s failed: %w", hook, command, err)
+			if hook == preLaunchHook {
+				return err
+			}
+			hooksErr = errors.Join(hooksErr, err)
+		}
+	}
+
+	return hooksErr
+}
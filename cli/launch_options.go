@@ -1,15 +1,16 @@
 package cli
 
 import (
+	"errors"
 	"maps"
 	"net/url"
 	"os"
 	"strings"
 
 	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/southern_light/wine_integration"
 	"github.com/arelate/theo/data"
 	"github.com/boggydigital/nod"
-	"github.com/boggydigital/redux"
 )
 
 var osEnvDefaults = map[vangogh_integration.OperatingSystem][]string{
@@ -37,7 +38,9 @@ func LaunchOptionsHandler(u *url.URL) error {
 	})
 
 	et := new(execTask{
-		exe: q.Get(vangogh_integration.UrlExeParameter),
+		exe:     q.Get(vangogh_integration.UrlExeParameter),
+		task:    q.Get(vangogh_integration.UrlTaskParameter),
+		profile: q.Get(data.UrlProfileParameter),
 	})
 
 	if q.Has(vangogh_integration.UrlEnvParameter) {
@@ -50,6 +53,36 @@ func LaunchOptionsHandler(u *url.URL) error {
 		}
 	}
 
+	if q.Has(data.UrlWrapperParameter) {
+		et.wrappers = splitEscaped(q.Get(data.UrlWrapperParameter))
+		for _, wrapper := range et.wrappers {
+			if _, err := splitWrapper(wrapper); err != nil {
+				return errors.Join(err, errors.New(`commas in wrappers need to be escaped, e.g. \,`))
+			}
+		}
+	}
+
+	if q.Has(data.UrlPreHookParameter) {
+		et.preHooks = splitEscaped(q.Get(data.UrlPreHookParameter))
+	}
+
+	if q.Has(data.UrlPostHookParameter) {
+		et.postHooks = splitEscaped(q.Get(data.UrlPostHookParameter))
+	}
+
+	protonRuntime := q.Get(vangogh_integration.UrlProtonRuntimeParameter)
+
+	for runtime, runtimeName := range wine_integration.ProtonRuntimesNames {
+		if runtimeName == protonRuntime {
+			et.protonRuntime = runtime
+			break
+		}
+	}
+
+	if q.Has(vangogh_integration.UrlProtonOptionParameter) {
+		et.protonOptions = strings.Split(q.Get(vangogh_integration.UrlProtonOptionParameter), ",")
+	}
+
 	reset := q.Has(vangogh_integration.UrlResetParameter)
 
 	return LaunchOptions(id, ii, et, reset)
@@ -60,7 +93,7 @@ func LaunchOptions(id string, request *InstallInfo, et *execTask, reset bool) er
 	loa := nod.Begin("setting launch options for %s...", id)
 	defer loa.Done()
 
-	rdx, err := redux.NewWriter(data.AbsReduxDir(), data.AllProperties()...)
+	rdx, err := newReduxWriter(data.AllProperties()...)
 	if err != nil {
 		return err
 	}
@@ -71,14 +104,19 @@ func LaunchOptions(id string, request *InstallInfo, et *execTask, reset bool) er
 	}
 
 	appOsLangCode := data.AppOsLangCode(id, ii.OperatingSystem, ii.LangCode)
+	launchOptionsKey := data.LaunchOptionsKey(id, ii.OperatingSystem, ii.LangCode, et.profile)
+	namedProfile := launchOptionsKey != appOsLangCode
 
 	if reset {
-		if err = rdx.CutKeys(data.LaunchOptionsExeProperty, appOsLangCode); err != nil {
-			return err
+		for _, lop := range launchOptionsProperties() {
+			if err = rdx.CutKeys(lop, launchOptionsKey); err != nil {
+				return err
+			}
 		}
 
-		if err = rdx.CutKeys(data.LaunchOptionsArgProperty, appOsLangCode); err != nil {
-			return err
+		// resetting named profile removes it
+		if namedProfile {
+			return rdx.CutValues(data.LaunchOptionsProfilesProperty, appOsLangCode, et.profile)
 		}
 
 		if err = rdx.ReplaceValues(data.LaunchOptionsEnvProperty, appOsLangCode, osEnvDefaults[data.CurrentOs()]...); err != nil {
@@ -86,17 +124,59 @@ func LaunchOptions(id string, request *InstallInfo, et *execTask, reset bool) er
 		}
 	}
 
+	if namedProfile && !rdx.HasValue(data.LaunchOptionsProfilesProperty, appOsLangCode, et.profile) {
+		if err = rdx.AddValues(data.LaunchOptionsProfilesProperty, appOsLangCode, et.profile); err != nil {
+			return err
+		}
+	}
+
 	if et.exe != "" {
 		if _, err = os.Stat(et.exe); err != nil {
 			return err
 		}
-		if err = rdx.ReplaceValues(data.LaunchOptionsExeProperty, appOsLangCode, et.exe); err != nil {
+		if err = rdx.ReplaceValues(data.LaunchOptionsExeProperty, launchOptionsKey, et.exe); err != nil {
 			return err
 		}
 	}
 
 	if len(et.args) > 0 {
-		if err = rdx.ReplaceValues(data.LaunchOptionsArgProperty, appOsLangCode, et.args...); err != nil {
+		if err = rdx.ReplaceValues(data.LaunchOptionsArgProperty, launchOptionsKey, et.args...); err != nil {
+			return err
+		}
+	}
+
+	if len(et.wrappers) > 0 {
+		if err = rdx.ReplaceValues(data.LaunchOptionsWrapperProperty, launchOptionsKey, et.wrappers...); err != nil {
+			return err
+		}
+	}
+
+	if len(et.preHooks) > 0 {
+		if err = rdx.ReplaceValues(data.LaunchOptionsPreHookProperty, launchOptionsKey, et.preHooks...); err != nil {
+			return err
+		}
+	}
+
+	if len(et.postHooks) > 0 {
+		if err = rdx.ReplaceValues(data.LaunchOptionsPostHookProperty, launchOptionsKey, et.postHooks...); err != nil {
+			return err
+		}
+	}
+
+	if et.protonRuntime != "" {
+		if err = rdx.ReplaceValues(data.LaunchOptionsProtonRuntimeProperty, launchOptionsKey, et.protonRuntime); err != nil {
+			return err
+		}
+	}
+
+	if len(et.protonOptions) > 0 {
+		if err = rdx.ReplaceValues(data.LaunchOptionsProtonOptionProperty, launchOptionsKey, et.protonOptions...); err != nil {
+			return err
+		}
+	}
+
+	if et.task != "" {
+		if err = rdx.ReplaceValues(data.LaunchOptionsTaskProperty, launchOptionsKey, et.task); err != nil {
 			return err
 		}
 	}
@@ -105,13 +185,13 @@ func LaunchOptions(id string, request *InstallInfo, et *execTask, reset bool) er
 
 		var newEnvs []string
 
-		if curEnv, ok := rdx.GetAllValues(data.LaunchOptionsEnvProperty, appOsLangCode); ok {
+		if curEnv, ok := rdx.GetAllValues(data.LaunchOptionsEnvProperty, launchOptionsKey); ok {
 			newEnvs = mergeEnv(curEnv, et.env)
 		} else {
 			newEnvs = et.env
 		}
 
-		if err = rdx.ReplaceValues(data.LaunchOptionsEnvProperty, appOsLangCode, newEnvs...); err != nil {
+		if err = rdx.ReplaceValues(data.LaunchOptionsEnvProperty, launchOptionsKey, newEnvs...); err != nil {
 			return err
 		}
 	}
@@ -119,6 +199,22 @@ func LaunchOptions(id string, request *InstallInfo, et *execTask, reset bool) er
 	return nil
 }
 
+// launchOptionsProperties returns properties that hold
+// launch options values for a launch options key
+func launchOptionsProperties() []string {
+	return []string{
+		data.LaunchOptionsExeProperty,
+		data.LaunchOptionsArgProperty,
+		data.LaunchOptionsEnvProperty,
+		data.LaunchOptionsWrapperProperty,
+		data.LaunchOptionsPreHookProperty,
+		data.LaunchOptionsPostHookProperty,
+		data.LaunchOptionsProtonRuntimeProperty,
+		data.LaunchOptionsProtonOptionProperty,
+		data.LaunchOptionsTaskProperty,
+	}
+}
+
 func mergeEnv(env1 []string, env2 []string) []string {
 	de1, de2 := decodeEnv(env1), decodeEnv(env2)
 	maps.Copy(de1, de2)
@@ -142,3 +238,26 @@ func encodeEnv(de map[string]string) []string {
 	}
 	return ee
 }
+
+// splitEscaped splits multiple values on commas, commas escaped
+// with a backslash are kept in the values, e.g. hook commands or wrappers
+func splitEscaped(values string) []string {
+
+	var split []string
+	var sb strings.Builder
+
+	for i := 0; i < len(values); i++ {
+		switch {
+		case values[i] == '\\' && i+1 < len(values) && values[i+1] == ',':
+			sb.WriteByte(',')
+			i++
+		case values[i] == ',':
+			split = append(split, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteByte(values[i])
+		}
+	}
+
+	return append(split, sb.String())
+}
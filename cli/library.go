@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/pathways"
+	"github.com/boggydigital/redux"
+)
+
+const defaultLibraryName = "default"
+
+func LibraryHandler(u *url.URL) error {
+
+	q := u.Query()
+
+	name := q.Get(data.UrlNameParameter)
+	path := q.Get(data.UrlPathParameter)
+	remove := q.Has(vangogh_integration.UrlRemoveParameter)
+
+	return Library(name, path, remove)
+}
+
+// Library configures library roots, additional locations (e.g. on other volumes)
+// that products can be installed to. Library roots mirror theo root installation
+// directories. Without a name configured library roots are listed
+func Library(name, path string, remove bool) error {
+
+	if name == "" {
+		return listLibraries()
+	}
+
+	la := nod.Begin("configuring library %s...", name)
+	defer la.Done()
+
+	rdx, err := newReduxWriter(data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	if err = rdx.MustHave(data.LibraryRootsProperty); err != nil {
+		return err
+	}
+
+	switch {
+	case name == defaultLibraryName:
+		return errors.New("default library is theo root and cannot be configured")
+	case remove:
+		return removeLibrary(name, rdx)
+	case path != "":
+		return setLibrary(name, path, rdx)
+	default:
+		return errors.New("library path is required")
+	}
+}
+
+func setLibrary(name, path string, rdx redux.Writeable) error {
+
+	absLibraryRoot, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	if err = mkdirLibraryDirs(absLibraryRoot); err != nil {
+		return err
+	}
+
+	return rdx.ReplaceValues(data.LibraryRootsProperty, name, absLibraryRoot)
+}
+
+func removeLibrary(name string, rdx redux.Writeable) error {
+
+	if !rdx.HasKey(data.LibraryRootsProperty, name) {
+		return errors.New("library not found: " + name)
+	}
+
+	libraryInstalls, err := getLibraryInstalls(rdx)
+	if err != nil {
+		return err
+	}
+
+	if ids := libraryInstalls[name]; len(ids) > 0 {
+		return errors.New("library " + name + " has installed products, please uninstall or move them first")
+	}
+
+	// library root directories are kept, as they might contain user files
+	return rdx.CutKeys(data.LibraryRootsProperty, name)
+}
+
+func listLibraries() error {
+
+	lla := nod.Begin("listing library roots...")
+	defer lla.Done()
+
+	rdx, err := newReduxWriter(data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	libraryInstalls, err := getLibraryInstalls(rdx)
+	if err != nil {
+		return err
+	}
+
+	summary := make(map[string][]string)
+
+	for _, library := range getLibraries(rdx) {
+
+		absLibraryRoot, err := data.AbsLibraryRoot(library, rdx)
+		if err != nil {
+			return err
+		}
+
+		heading := library
+		if heading == "" {
+			heading = defaultLibraryName
+		}
+
+		summary[heading] = []string{
+			"path: " + absLibraryRoot,
+			"installed: " + strconv.Itoa(len(libraryInstalls[library])),
+		}
+	}
+
+	lla.EndWithSummary("library roots:", summary)
+
+	return nil
+}
+
+// getLibraries returns default library followed by the configured library names
+func getLibraries(rdx redux.Readable) []string {
+
+	libraries := []string{""}
+	for name := range rdx.Keys(data.LibraryRootsProperty) {
+		libraries = append(libraries, name)
+	}
+	slices.Sort(libraries[1:])
+
+	return libraries
+}
+
+// getLibraryInstalls returns installed product ids for each library
+func getLibraryInstalls(rdx redux.Readable) (map[string][]string, error) {
+
+	installedInfos, err := getInstalledInfos(rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	libraryInstalls := make(map[string][]string)
+
+	for id, iis := range installedInfos {
+		for _, ii := range iis {
+			libraryInstalls[ii.Library] = append(libraryInstalls[ii.Library], id)
+		}
+	}
+
+	return libraryInstalls, nil
+}
+
+func mkdirLibraryDirs(absLibraryRoot string) error {
+	for _, ld := range data.LibraryDirs {
+		if err := os.MkdirAll(filepath.Join(absLibraryRoot, string(ld)), pathways.PermUrwGrwOr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseLibrary returns library name or absolute library root
+// directory. Default library is stored as an empty library
+func parseLibrary(library string) (string, error) {
+	switch {
+	case library == defaultLibraryName:
+		return "", nil
+	case filepath.IsAbs(library) || strings.ContainsRune(library, filepath.Separator):
+		return filepath.Abs(library)
+	default:
+		return library, nil
+	}
+}
+
+// originLibraryDir returns installation library directory, making sure it exists
+// (e.g. free space can only be checked for existing directories)
+func originLibraryDir(ii *InstallInfo, ad pathways.AbsDir, rdx redux.Readable) (string, error) {
+
+	absLibraryDir, err := data.AbsLibraryDir(ii.Library, ad, rdx)
+	if err != nil {
+		return "", err
+	}
+
+	if err = os.MkdirAll(absLibraryDir, pathways.PermUrwGrwOr); err != nil {
+		return "", err
+	}
+
+	return absLibraryDir, nil
+}
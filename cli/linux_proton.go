@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
@@ -66,7 +65,10 @@ func linuxProtonExecTask(id string, et *execTask) error {
 		return err
 	}
 
-	cmd := exec.Command(absUmuRunPath, "--config", absUmuConfigPath)
+	cmd, err := execTaskCommand(et, absUmuRunPath, "--config", absUmuConfigPath)
+	if err != nil {
+		return err
+	}
 
 	if et.workDir != "" {
 		cmd.Dir = et.workDir
@@ -80,12 +82,11 @@ func linuxProtonExecTask(id string, et *execTask) error {
 		}
 	}
 
-	if et.verbose {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+	if err = setExecTaskOutput(cmd, et); err != nil {
+		return err
 	}
 
-	return cmd.Run()
+	return runSupervised(cmd, et)
 }
 
 func linuxInitPrefix(absPrefixDir string, _ bool) error {
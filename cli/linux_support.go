@@ -147,6 +147,16 @@ func chmodExecutable(path string) error {
 	return cmd.Run()
 }
 
+// linuxNotify shows desktop notification using freedesktop notifications D-Bus interface
+func linuxNotify(summary, body string) error {
+	cmd := exec.Command("gdbus", "call", "--session",
+		"--dest", "org.freedesktop.Notifications",
+		"--object-path", "/org/freedesktop/Notifications",
+		"--method", "org.freedesktop.Notifications.Notify",
+		"theo", "0", "", summary, body, "[]", "{}", "-1")
+	return cmd.Run()
+}
+
 func linuxReveal(path string) error {
 	cmd := exec.Command("xdg-open", path)
 	return cmd.Run()
@@ -159,23 +169,26 @@ func nixRunExecTask(et *execTask) error {
 
 	if data.CurrentOs() == vangogh_integration.MacOS &&
 		strings.HasSuffix(et.exe, appBundleExt) {
-		et.args = append([]string{et.exe, "--args"}, et.args...)
+		// wait for the app to quit, so that the run can be supervised
+		et.args = append([]string{"-W", et.exe, "--args"}, et.args...)
 		et.exe = "open"
 	}
 
-	cmd := exec.Command(et.exe, et.args...)
+	cmd, err := execTaskCommand(et, et.exe, et.args...)
+	if err != nil {
+		return err
+	}
 	cmd.Dir = et.workDir
 
-	if et.verbose {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+	if err := setExecTaskOutput(cmd, et); err != nil {
+		return err
 	}
 
 	for _, e := range et.env {
 		cmd.Env = append(cmd.Env, e)
 	}
 
-	return cmd.Run()
+	return runSupervised(cmd, et)
 }
 
 func linuxFindStartSh(id string, ii *InstallInfo, rdx redux.Readable) (string, error) {
@@ -13,6 +13,7 @@ import (
 	"github.com/arelate/southern_light/egs_integration"
 	"github.com/arelate/southern_light/gog_integration"
 	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/southern_light/wine_integration"
 	"github.com/arelate/theo/data"
 	"github.com/boggydigital/nod"
 	"github.com/boggydigital/pathways"
@@ -83,12 +84,24 @@ func ListHandler(u *url.URL) error {
 
 	update := q.Has(vangogh_integration.UrlUpdateParameter)
 
-	return List(lt, ii, id, update)
+	format := q.Get(data.UrlFormatParameter)
+
+	return List(lt, ii, id, update, format)
 }
 
 func List(lt listTarget,
 	installInfo *InstallInfo,
-	id string, update bool) error {
+	id string, update bool,
+	format string) error {
+
+	if format == JsonFormat {
+		switch lt {
+		case ListTargetInstalled:
+			return listInstalledJson(installInfo)
+		default:
+			return errors.New("json format is only supported for installed products")
+		}
+	}
 
 	switch lt {
 	case ListTargetAvailableProducts:
@@ -120,7 +133,7 @@ func listAvailableProducts(ii *InstallInfo, update bool) error {
 	var availableProducts []vangogh_integration.AvailableProduct
 	var err error
 
-	rdx, err := redux.NewWriter(data.AbsReduxDir(), data.AllProperties()...)
+	rdx, err := newReduxWriter(data.AllProperties()...)
 	if err != nil {
 		return err
 	}
@@ -179,152 +192,208 @@ func listInstalled(ii *InstallInfo) error {
 	lia := nod.Begin("listing installed products for %s, %s...", ii.OperatingSystem, ii.LangCode)
 	defer lia.Done()
 
-	rdx, err := redux.NewReader(data.AbsReduxDir(),
-		vangogh_integration.GogTitleProperty,
-		vangogh_integration.SteamTitleProperty,
-		vangogh_integration.EgsTitleProperty,
-		vangogh_integration.GogBundleNameProperty,
-		data.InstallInfoProperty,
-		data.InstallDateProperty,
-		data.LastRunDateProperty,
-		data.TotalPlaytimeMinutesProperty)
+	installedProducts, err := getInstalledProducts(ii)
 	if err != nil {
 		return err
 	}
 
 	summary := make(map[string][]string)
+	playtimes := make(map[string]string)
 
-	installedIds := slices.Collect(rdx.Keys(data.InstallInfoProperty))
+	for _, ip := range installedProducts {
 
-	for _, id := range installedIds {
+		titleLine := fmt.Sprintf("%s: %s", ip.Origin, ip.Id)
 
-		var installedDate string
-		if ids, ok := rdx.GetLastVal(data.InstallDateProperty, id); ok && ids != "" {
-			var installDate time.Time
-			if installDate, err = time.Parse(time.RFC3339, ids); err == nil {
-				installedDate = installDate.Local().Format(time.DateTime)
-			} else {
-				return err
-			}
+		var installDir string
+		if ip.Title != "" {
+			titleLine = fmt.Sprintf("%s (%s)", ip.Title, titleLine)
+			installDir = pathways.Sanitize(ip.Title)
 		}
 
-		var titleLine string
+		infoLines := make([]string, 0)
 
-		filteredIds := make(map[string]any)
+		infoLines = append(infoLines, "os: "+ip.OperatingSystem.String())
+		infoLines = append(infoLines, "lang: "+gog_integration.LanguageNativeName(ip.LangCode))
 
-		installedInfoLines, ok := rdx.GetAllValues(data.InstallInfoProperty, id)
-		if !ok {
-			return errors.New("install info not found for " + id)
+		if ip.Version != "" {
+			infoLines = append(infoLines, "version: "+ip.Version)
 		}
 
-		for _, line := range installedInfoLines {
+		if ip.TimeUpdated != "" {
+			infoLines = append(infoLines, "updated: "+ip.TimeUpdated)
+		}
 
-			var installedInfo InstallInfo
-			if err = json.UnmarshalRead(strings.NewReader(line), &installedInfo); err != nil {
+		if ip.HeldVersion != "" {
+			infoLines = append(infoLines, "held: "+ip.HeldVersion)
+		}
+
+		if ip.EstimatedBytes > 0 {
+			infoLines = append(infoLines, "size: "+vangogh_integration.FormatBytes(ip.EstimatedBytes))
+		}
+
+		if ip.Library != "" {
+			infoLines = append(infoLines, "library: "+ip.Library)
+		}
+
+		summary[titleLine] = append(summary[titleLine], strings.Join(infoLines, "; "))
+
+		if len(ip.DownloadableContent) > 0 {
+			summary[titleLine] = append(summary[titleLine], "- dlc: "+strings.Join(ip.DownloadableContent, ", "))
+		}
+
+		if ip.InstallDate != "" {
+			installDate, err := time.Parse(time.RFC3339, ip.InstallDate)
+			if err != nil {
 				return err
 			}
+			installStr := "- installed: " + installDate.Local().Format(time.DateTime)
+			if installDir != "" {
+				installStr += "; dir: " + installDir
+			}
+			summary[titleLine] = append(summary[titleLine], installStr)
+		}
 
-			if ii.OperatingSystem != vangogh_integration.AnyOperatingSystem && ii.OperatingSystem != installedInfo.OperatingSystem {
-				filteredIds[id] = nil
-				continue
+		// playtimes
+
+		var playtimeStr string
+
+		if ip.TotalPlaytimeMinutes > 0 {
+			playtimeStr = "- total playtime: " + fmtHoursMinutes(ip.TotalPlaytimeMinutes)
+		}
+
+		if ip.LastRunDate != "" {
+			lrdt, err := time.Parse(time.RFC3339, ip.LastRunDate)
+			if err != nil {
+				return err
 			}
+			lastRunDate := "last run date: " + lrdt.Format(time.DateTime)
 
-			if ii.LangCode != "" && ii.LangCode != installedInfo.LangCode {
-				filteredIds[id] = nil
-				continue
+			switch playtimeStr {
+			case "":
+				playtimeStr = "- " + lastRunDate
+			default:
+				playtimeStr += "; " + lastRunDate
 			}
+		}
 
-			var installDir string
+		if playtimeStr != "" {
+			playtimes[titleLine] = playtimeStr
+		}
+	}
 
-			titleLine = fmt.Sprintf("%s: %s", installedInfo.Origin, id)
+	// playtimes are listed once per product, after all of its installations
+	for titleLine, playtimeStr := range playtimes {
+		summary[titleLine] = append(summary[titleLine], playtimeStr)
+	}
 
-			if title, terr := data.GetTitleProperty(id, rdx); terr == nil && title != "" {
-				titleLine = fmt.Sprintf("%s (%s)", title, titleLine)
-				installDir = pathways.Sanitize(title)
-			} else if terr != nil {
-				return terr
-			}
+	if len(summary) == 0 {
+		lia.EndWithResult("found nothing")
+	} else {
+		lia.EndWithSummary("found the following products:", summary)
+	}
 
-			infoLines := make([]string, 0)
+	return nil
+}
 
-			infoLines = append(infoLines, "os: "+installedInfo.OperatingSystem.String())
-			infoLines = append(infoLines, "lang: "+gog_integration.LanguageNativeName(installedInfo.LangCode))
+type installedProduct struct {
+	Id                   string `json:"id"`
+	Title                string `json:"title"`
+	InstallInfo          `json:",inline"`
+	InstallDate          string `json:"install-date,omitempty"`
+	LastRunDate          string `json:"last-run-date,omitempty"`
+	HeldVersion          string `json:"held-version,omitempty"`
+	TotalPlaytimeMinutes int64  `json:"total-playtime-minutes"`
+}
 
-			if installedInfo.Version != "" {
-				infoLines = append(infoLines, "version: "+installedInfo.Version)
-			}
+func listInstalledJson(ii *InstallInfo) error {
 
-			if installedInfo.TimeUpdated != "" {
-				infoLines = append(infoLines, "updated: "+installedInfo.TimeUpdated)
-			}
+	installedProducts, err := getInstalledProducts(ii)
+	if err != nil {
+		return err
+	}
 
-			if installedInfo.EstimatedBytes > 0 {
-				infoLines = append(infoLines, "size: "+vangogh_integration.FormatBytes(installedInfo.EstimatedBytes))
-			}
+	return writeJson(installedProducts)
+}
 
-			summary[titleLine] = append(summary[titleLine], strings.Join(infoLines, "; "))
+// getInstalledProducts returns installations matching operating system,
+// language and origin of the request, sorted by product id
+func getInstalledProducts(ii *InstallInfo) ([]installedProduct, error) {
 
-			if len(installedInfo.DownloadableContent) > 0 {
-				summary[titleLine] = append(summary[titleLine], "- dlc: "+strings.Join(installedInfo.DownloadableContent, ", "))
-			}
+	rdx, err := newReduxReader(
+		vangogh_integration.GogTitleProperty,
+		vangogh_integration.SteamTitleProperty,
+		vangogh_integration.EgsTitleProperty,
+		vangogh_integration.GogBundleNameProperty,
+		data.InstallInfoProperty,
+		data.InstallDateProperty,
+		data.LastRunDateProperty,
+		data.TotalPlaytimeMinutesProperty,
+		data.HoldProperty)
+	if err != nil {
+		return nil, err
+	}
 
-			if installedDate != "" {
-				installStr := "- installed: " + installedDate
-				if installDir != "" {
-					installStr += "; dir: " + installDir
-				}
-				summary[titleLine] = append(summary[titleLine], installStr)
-			}
-		}
+	installedProducts := make([]installedProduct, 0)
 
-		// playtimes
+	installedIds := slices.Collect(rdx.Keys(data.InstallInfoProperty))
+	slices.Sort(installedIds)
+
+	for _, id := range installedIds {
 
-		if _, filtered := filteredIds[id]; filtered {
-			continue
+		title, err := data.GetTitleProperty(id, rdx)
+		if err != nil {
+			return nil, err
 		}
 
-		var playtimeStr string
+		installDate, _ := rdx.GetLastVal(data.InstallDateProperty, id)
+		lastRunDate, _ := rdx.GetLastVal(data.LastRunDateProperty, id)
 
-		if tpms, sure := rdx.GetLastVal(data.TotalPlaytimeMinutesProperty, id); sure && tpms != "" {
-			var tpmi int64
-			if tpmi, err = strconv.ParseInt(tpms, 10, 64); err == nil {
-				if tpmi > 0 {
-					playtimeStr = "- total playtime: " + fmtHoursMinutes(tpmi)
-				}
-			} else {
-				return err
+		var totalPlaytimeMinutes int64
+		if tpms, ok := rdx.GetLastVal(data.TotalPlaytimeMinutesProperty, id); ok && tpms != "" {
+			if totalPlaytimeMinutes, err = strconv.ParseInt(tpms, 10, 64); err != nil {
+				return nil, err
 			}
 		}
 
-		if lrds, sure := rdx.GetLastVal(data.LastRunDateProperty, id); sure && lrds != "" {
-			var lrdt time.Time
-			if lrdt, err = time.Parse(time.RFC3339, lrds); err == nil {
-				lastRunDate := "last run date: " + lrdt.Format(time.DateTime)
+		installedInfoLines, ok := rdx.GetAllValues(data.InstallInfoProperty, id)
+		if !ok {
+			return nil, errors.New("install info not found for " + id)
+		}
+
+		for _, line := range installedInfoLines {
 
-				switch playtimeStr {
-				case "":
-					playtimeStr = "- " + lastRunDate
-				default:
-					playtimeStr += "; " + lastRunDate
-				}
-			} else {
-				return err
+			var installedInfo InstallInfo
+			if err = json.UnmarshalRead(strings.NewReader(line), &installedInfo); err != nil {
+				return nil, err
 			}
-		}
 
-		if playtimeStr != "" {
-			summary[titleLine] = append(summary[titleLine], playtimeStr)
-		}
+			if ii.OperatingSystem != vangogh_integration.AnyOperatingSystem && ii.OperatingSystem != installedInfo.OperatingSystem {
+				continue
+			}
 
-	}
+			if ii.LangCode != "" && ii.LangCode != installedInfo.LangCode {
+				continue
+			}
 
-	if len(summary) == 0 {
-		lia.EndWithResult("found nothing")
-	} else {
-		lia.EndWithSummary("found the following products:", summary)
+			if ii.Origin != data.UnknownOrigin && ii.Origin != installedInfo.Origin {
+				continue
+			}
+
+			held, _ := heldVersion(id, &installedInfo, rdx)
+
+			installedProducts = append(installedProducts, installedProduct{
+				Id:                   id,
+				Title:                title,
+				InstallInfo:          installedInfo,
+				InstallDate:          installDate,
+				LastRunDate:          lastRunDate,
+				HeldVersion:          held,
+				TotalPlaytimeMinutes: totalPlaytimeMinutes,
+			})
+		}
 	}
 
-	return nil
+	return installedProducts, nil
 }
 
 func listLaunchOptions(id string, request *InstallInfo) error {
@@ -332,7 +401,7 @@ func listLaunchOptions(id string, request *InstallInfo) error {
 	lloa := nod.Begin("listing launch options for %s...", id)
 	defer lloa.Done()
 
-	rdx, err := redux.NewWriter(data.AbsReduxDir(), data.AllProperties()...)
+	rdx, err := newReduxWriter(data.AllProperties()...)
 	if err != nil {
 		return err
 	}
@@ -346,15 +415,33 @@ func listLaunchOptions(id string, request *InstallInfo) error {
 
 	summary := make(map[string][]string)
 
-	launchOptionsProperties := []string{
-		data.LaunchOptionsExeProperty,
-		data.LaunchOptionsArgProperty,
-		data.LaunchOptionsEnvProperty,
+	profiles := []string{data.DefaultLaunchProfile}
+	if namedProfiles, ok := rdx.GetAllValues(data.LaunchOptionsProfilesProperty, appOsLangCode); ok {
+		profiles = append(profiles, namedProfiles...)
 	}
 
-	for _, lop := range launchOptionsProperties {
-		if values, ok := rdx.GetAllValues(lop, appOsLangCode); ok && len(values) > 0 {
-			summary[lop] = values
+	for _, profile := range profiles {
+
+		launchOptionsKey := data.LaunchOptionsKey(id, installedInfo.OperatingSystem, installedInfo.LangCode, profile)
+		heading := "profile: " + profile
+
+		for _, lop := range launchOptionsProperties() {
+			values, ok := rdx.GetAllValues(lop, launchOptionsKey)
+			if !ok {
+				continue
+			}
+			option := strings.TrimPrefix(lop, "launch-options-")
+			for _, value := range values {
+				if name, ok := wine_integration.ProtonRuntimesNames[value]; ok && lop == data.LaunchOptionsProtonRuntimeProperty {
+					value = name
+				}
+				summary[heading] = append(summary[heading], option+": "+value)
+			}
+		}
+
+		// named profiles are listed even when they don't have any values yet
+		if _, ok := summary[heading]; !ok && profile != data.DefaultLaunchProfile {
+			summary[heading] = []string{}
 		}
 	}
 
@@ -372,7 +459,7 @@ func listTasks(id string, request *InstallInfo) error {
 	lpta := nod.Begin("listing tasks for %s...", id)
 	defer lpta.Done()
 
-	rdx, err := redux.NewWriter(data.AbsReduxDir(), data.AllProperties()...)
+	rdx, err := newReduxWriter(data.AllProperties()...)
 	if err != nil {
 		return err
 	}
@@ -389,6 +476,8 @@ func listTasks(id string, request *InstallInfo) error {
 		tasksSummary, err = listGogInfoPlayTasks(id, installedInfo, rdx)
 	case data.SteamOrigin:
 		tasksSummary, err = listSteamAppInfoTasks(id, rdx, installedInfo.force)
+	case data.EpicGamesOrigin:
+		tasksSummary, err = listEpicGamesTasks(id, installedInfo, rdx)
 	default:
 		err = installedInfo.Origin.ErrUnsupportedOrigin()
 	}
@@ -495,8 +584,31 @@ func listSteamAppInfoTasks(steamAppId string, rdx redux.Writeable, force bool) (
 	return steamLaunchConfigTasks, nil
 }
 
-func listEpicGamesTasks(appName string) (map[string][]string, error) {
-	return nil, nil
+func listEpicGamesTasks(appName string, ii *InstallInfo, rdx redux.Writeable) (map[string][]string, error) {
+
+	originData, err := originGetData(appName, ii, rdx, ii.force)
+	if err != nil {
+		return nil, err
+	}
+
+	egsLaunchTasks := make(map[string][]string)
+
+	for _, lt := range egsGetLaunchTasks(originData) {
+
+		list := make([]string, 0)
+
+		list = append(list, "exe:"+lt.exe)
+		if len(lt.args) > 0 {
+			list = append(list, "arguments:"+strings.Join(lt.args, " "))
+		}
+		if lt.isPrimary {
+			list = append(list, "isPrimary:true")
+		}
+
+		egsLaunchTasks["name:"+lt.name] = list
+	}
+
+	return egsLaunchTasks, nil
 }
 
 func listSteamShortcuts() error {
@@ -0,0 +1,501 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/redux"
+)
+
+const (
+	reduxLockName         = "redux"
+	lockExt               = ".lock"
+	reduxLockGracePeriod  = 10 * time.Second
+	lockRetryInterval     = 100 * time.Millisecond
+	lockHolderDescription = "pid %d: %s"
+)
+
+// waitForLocks makes commands wait for locks held by other theo processes
+// instead of failing
+var waitForLocks atomic.Bool
+
+var (
+	heldLocksMtx sync.Mutex
+	heldLocks    = make(map[string]*fileLock)
+	reduxMtx     sync.Mutex
+)
+
+func SetWaitForLocks(u *url.URL) {
+	waitForLocks.Store(u != nil && u.Query().Has(data.UrlWaitParameter))
+}
+
+type fileLock struct {
+	name        string
+	description string
+	file        *os.File
+	owner       uint64
+	count       int
+	// released is closed when the lock is released by its owner
+	released chan struct{}
+}
+
+func absLockPath(name string) string {
+	return filepath.Join(data.Pwd.AbsRelDirPath(data.Locks, data.Temp), name+lockExt)
+}
+
+// lockOwner identifies the caller by the goroutine id, so that locks are re-entrant
+// for the nested calls, while concurrent callers in the same process (e.g. serve jobs)
+// exclude each other
+func lockOwner() uint64 {
+	buf := make([]byte, 64)
+	// stack starts with "goroutine N [status]:"
+	fields := strings.Fields(string(buf[:runtime.Stack(buf, false)]))
+	if len(fields) < 2 {
+		return 0
+	}
+	id, _ := strconv.ParseUint(fields[1], 10, 64)
+	return id
+}
+
+// acquireLock takes an exclusive advisory lock with a given name. Locks are
+// re-entrant for the same caller. When the lock is held by another caller or process
+// acquireLock waits for it indefinitely if wait is set, otherwise it retries for
+// the grace period and then fails with the description of the current holder
+func acquireLock(name, description string, wait bool, gracePeriod time.Duration) (*fileLock, error) {
+
+	owner := lockOwner()
+	deadline := time.Now().Add(gracePeriod)
+
+	var fl *fileLock
+
+	// reserve the lock within the process first, waiting for other callers without
+	// holding heldLocksMtx, so that they can release the lock in the meantime
+	for fl == nil {
+
+		heldLocksMtx.Lock()
+
+		held, ok := heldLocks[name]
+		switch {
+		case !ok:
+			fl = &fileLock{
+				name:        name,
+				description: description,
+				owner:       owner,
+				count:       1,
+				released:    make(chan struct{}),
+			}
+			heldLocks[name] = fl
+		case held.owner == owner:
+			held.count++
+			heldLocksMtx.Unlock()
+			return held, nil
+		}
+
+		heldLocksMtx.Unlock()
+
+		if fl != nil {
+			break
+		}
+
+		switch wait {
+		case true:
+			<-held.released
+		case false:
+			select {
+			case <-held.released:
+			case <-time.After(time.Until(deadline)):
+				return nil, fmt.Errorf("%s is locked by another theo job (%s)", name, held.description)
+			}
+		}
+	}
+
+	file, err := lockNamedFile(name, description, wait, deadline)
+	if err != nil {
+		heldLocksMtx.Lock()
+		delete(heldLocks, name)
+		heldLocksMtx.Unlock()
+		close(fl.released)
+		return nil, err
+	}
+
+	fl.file = file
+
+	return fl, nil
+}
+
+// lockNamedFile takes the lock file for the name, excluding other theo processes
+func lockNamedFile(name, description string, wait bool, deadline time.Time) (*os.File, error) {
+
+	file, err := os.OpenFile(absLockPath(name), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	switch wait {
+	case true:
+		err = lockFile(file)
+	case false:
+		for {
+			var locked bool
+			if locked, err = tryLockFile(file); locked || err != nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				holder := lockHolder(file)
+				if cerr := file.Close(); cerr != nil {
+					return nil, cerr
+				}
+				return nil, fmt.Errorf("%s is locked by another theo process (%s)", name, holder)
+			}
+			time.Sleep(lockRetryInterval)
+		}
+	}
+
+	if err != nil {
+		if cerr := file.Close(); cerr != nil {
+			return nil, cerr
+		}
+		return nil, err
+	}
+
+	if err = file.Truncate(0); err != nil {
+		return nil, err
+	}
+	if _, err = file.WriteAt([]byte(fmt.Sprintf(lockHolderDescription, os.Getpid(), description)), 0); err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func lockHolder(lockFile *os.File) string {
+	if _, err := lockFile.Seek(0, io.SeekStart); err != nil {
+		return "unknown holder"
+	}
+	if bts, err := io.ReadAll(lockFile); err == nil && len(bts) > 0 {
+		return strings.TrimSpace(string(bts))
+	}
+	return "unknown holder"
+}
+
+func (fl *fileLock) release() error {
+
+	heldLocksMtx.Lock()
+
+	fl.count--
+	if fl.count > 0 {
+		heldLocksMtx.Unlock()
+		return nil
+	}
+
+	heldLocksMtx.Unlock()
+
+	// other callers keep waiting until the lock file is unlocked
+	defer func() {
+		heldLocksMtx.Lock()
+		delete(heldLocks, fl.name)
+		heldLocksMtx.Unlock()
+		close(fl.released)
+	}()
+
+	if err := fl.file.Truncate(0); err != nil {
+		return err
+	}
+
+	if err := unlockFile(fl.file); err != nil {
+		return err
+	}
+
+	return fl.file.Close()
+}
+
+func productLockName(id string, ii *InstallInfo) string {
+	return strings.Join([]string{ii.Origin.String(), data.AppOsLangCode(id, ii.OperatingSystem, ii.LangCode)}, "-")
+}
+
+// getHeldProductLocks returns descriptions of the product locks currently held
+// by other theo processes, e.g. installations in progress
+func getHeldProductLocks() ([]string, error) {
+
+	absLocksDir := data.Pwd.AbsRelDirPath(data.Locks, data.Temp)
+
+	entries, err := os.ReadDir(absLocksDir)
+	if err != nil {
+		return nil, err
+	}
+
+	heldProductLocks := make([]string, 0)
+
+	for _, entry := range entries {
+
+		if entry.IsDir() || filepath.Ext(entry.Name()) != lockExt || entry.Name() == reduxLockName+lockExt {
+			continue
+		}
+
+		file, err := os.Open(filepath.Join(absLocksDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var locked bool
+		if locked, err = tryLockFile(file); err == nil {
+			switch locked {
+			case true:
+				err = unlockFile(file)
+			case false:
+				heldProductLocks = append(heldProductLocks, lockHolder(file))
+			}
+		}
+
+		if cerr := file.Close(); cerr != nil {
+			return nil, cerr
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return heldProductLocks, nil
+}
+
+// isProductLocked checks if the product is being installed, updated, etc.
+// by this or another theo process, without taking the lock
+func isProductLocked(id string, ii *InstallInfo) (bool, error) {
+
+	name := productLockName(id, ii)
+
+	heldLocksMtx.Lock()
+	_, held := heldLocks[name]
+	heldLocksMtx.Unlock()
+
+	if held {
+		return true, nil
+	}
+
+	file, err := os.Open(absLockPath(name))
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	locked, err := tryLockFile(file)
+	if err != nil {
+		return false, err
+	}
+
+	if locked {
+		return false, unlockFile(file)
+	}
+
+	return true, nil
+}
+
+func lockProduct(id string, ii *InstallInfo, description string) (*fileLock, error) {
+	return acquireLock(productLockName(id, ii), description+" "+id, waitForLocks.Load(), 0)
+}
+
+// lockedWriter serializes redux writes across theo processes: every write holds
+// the redux lock and is applied to the written asset reloaded from disk, so that
+// changes made by other processes are not overwritten. Reads are not locked: written
+// assets are read from their reloaded copies, other assets are read as they were
+// loaded and see changes made by other processes only after a refresh
+type lockedWriter struct {
+	redux.Writeable
+	mtx     sync.RWMutex
+	written map[string]redux.Writeable
+}
+
+// sharedRdx is the redux handle kept open by serve and shared by its jobs
+var sharedRdx redux.Writeable
+
+func newReduxWriter(assets ...string) (redux.Writeable, error) {
+
+	if sharedRdx != nil {
+		return sharedRdx.RefreshWriter()
+	}
+
+	rdx, err := redux.NewWriter(data.AbsReduxDir(), assets...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lockedWriter{
+		Writeable: rdx,
+		written:   make(map[string]redux.Writeable),
+	}, nil
+}
+
+// newReduxReader returns shared redux handle when serving, otherwise opens a reader
+func newReduxReader(assets ...string) (redux.Readable, error) {
+
+	if sharedRdx != nil {
+		return sharedRdx.RefreshReader()
+	}
+
+	return redux.NewReader(data.AbsReduxDir(), assets...)
+}
+
+func (lw *lockedWriter) locked(asset string, write func(rdx redux.Writeable) error) error {
+
+	if !lw.HasAsset(asset) {
+		return redux.ErrUnknownAsset(asset)
+	}
+
+	reduxMtx.Lock()
+	defer reduxMtx.Unlock()
+
+	rl, err := acquireLock(reduxLockName, "writing metadata", waitForLocks.Load(), reduxLockGracePeriod)
+	if err != nil {
+		return err
+	}
+	defer rl.release()
+
+	// reload only the written asset, as it might have been changed by another process
+	assetWriter, err := redux.NewWriter(data.AbsReduxDir(), asset)
+	if err != nil {
+		return err
+	}
+
+	if err = write(assetWriter); err != nil {
+		return err
+	}
+
+	lw.mtx.Lock()
+	lw.written[asset] = assetWriter
+	lw.mtx.Unlock()
+
+	return nil
+}
+
+// reader returns the reader that has the latest written data for the assets
+func (lw *lockedWriter) reader(assets ...string) redux.Readable {
+
+	lw.mtx.RLock()
+	defer lw.mtx.RUnlock()
+
+	var written []string
+	for _, asset := range assets {
+		if _, ok := lw.written[asset]; ok {
+			written = append(written, asset)
+		}
+	}
+
+	switch {
+	case len(written) == 0:
+		return lw.Writeable
+	case len(assets) == 1:
+		return lw.written[assets[0]]
+	default:
+		// multiple assets are reloaded together when any of them was written
+		if rdx, err := redux.NewReader(data.AbsReduxDir(), assets...); err == nil {
+			return rdx
+		}
+		return lw.Writeable
+	}
+}
+
+func (lw *lockedWriter) Keys(asset string) iter.Seq[string] {
+	return lw.reader(asset).Keys(asset)
+}
+
+func (lw *lockedWriter) Len(asset string) int {
+	return lw.reader(asset).Len(asset)
+}
+
+func (lw *lockedWriter) HasKey(asset, key string) bool {
+	return lw.reader(asset).HasKey(asset, key)
+}
+
+func (lw *lockedWriter) HasValue(asset, key, val string) bool {
+	return lw.reader(asset).HasValue(asset, key, val)
+}
+
+func (lw *lockedWriter) GetAllValues(asset, key string) ([]string, bool) {
+	return lw.reader(asset).GetAllValues(asset, key)
+}
+
+func (lw *lockedWriter) GetLastVal(asset, key string) (string, bool) {
+	return lw.reader(asset).GetLastVal(asset, key)
+}
+
+func (lw *lockedWriter) ParseLastValTime(asset, key string) (time.Time, bool, error) {
+	return lw.reader(asset).ParseLastValTime(asset, key)
+}
+
+func (lw *lockedWriter) MatchAsset(asset string, terms []string, scope iter.Seq[string], options ...redux.MatchOption) iter.Seq[string] {
+	return lw.reader(asset).MatchAsset(asset, terms, scope, options...)
+}
+
+func (lw *lockedWriter) Match(query map[string][]string, options ...redux.MatchOption) iter.Seq[string] {
+	return lw.reader(slices.Collect(maps.Keys(query))...).Match(query, options...)
+}
+
+func (lw *lockedWriter) Sort(ids []string, desc bool, sortBy ...string) ([]string, error) {
+	return lw.reader(sortBy...).Sort(ids, desc, sortBy...)
+}
+
+func (lw *lockedWriter) AddValues(asset, key string, values ...string) error {
+	return lw.locked(asset, func(rdx redux.Writeable) error { return rdx.AddValues(asset, key, values...) })
+}
+
+func (lw *lockedWriter) BatchAddValues(asset string, keyValues map[string][]string) error {
+	return lw.locked(asset, func(rdx redux.Writeable) error { return rdx.BatchAddValues(asset, keyValues) })
+}
+
+func (lw *lockedWriter) ReplaceValues(asset, key string, values ...string) error {
+	return lw.locked(asset, func(rdx redux.Writeable) error { return rdx.ReplaceValues(asset, key, values...) })
+}
+
+func (lw *lockedWriter) BatchReplaceValues(asset string, keyValues map[string][]string) error {
+	return lw.locked(asset, func(rdx redux.Writeable) error { return rdx.BatchReplaceValues(asset, keyValues) })
+}
+
+func (lw *lockedWriter) CutKeys(asset string, keys ...string) error {
+	return lw.locked(asset, func(rdx redux.Writeable) error { return rdx.CutKeys(asset, keys...) })
+}
+
+func (lw *lockedWriter) CutValues(asset, key string, values ...string) error {
+	return lw.locked(asset, func(rdx redux.Writeable) error { return rdx.CutValues(asset, key, values...) })
+}
+
+func (lw *lockedWriter) BatchCutValues(asset string, keyValues map[string][]string) error {
+	return lw.locked(asset, func(rdx redux.Writeable) error { return rdx.BatchCutValues(asset, keyValues) })
+}
+
+func (lw *lockedWriter) RefreshWriter() (redux.Writeable, error) {
+
+	lw.mtx.Lock()
+	defer lw.mtx.Unlock()
+
+	var err error
+	if lw.Writeable, err = lw.Writeable.RefreshWriter(); err != nil {
+		return nil, err
+	}
+
+	for asset, assetWriter := range lw.written {
+		if lw.written[asset], err = assetWriter.RefreshWriter(); err != nil {
+			return nil, err
+		}
+	}
+
+	return lw, nil
+}
+
+func (lw *lockedWriter) RefreshReader() (redux.Readable, error) {
+	return lw.RefreshWriter()
+}
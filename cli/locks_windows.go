@@ -0,0 +1,39 @@
+//go:build windows
+
+package cli
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// Windows locks are mandatory, so the locked range starts past any holder
+// description written to the lock file to keep it readable by other processes
+const lockRangeOffsetHigh = 0x7fffffff
+
+func lockFileEx(file *os.File, flags uint32) error {
+	ol := &windows.Overlapped{OffsetHigh: lockRangeOffsetHigh}
+	return windows.LockFileEx(windows.Handle(file.Fd()), flags, 0, 1, 0, ol)
+}
+
+func lockFile(file *os.File) error {
+	return lockFileEx(file, windows.LOCKFILE_EXCLUSIVE_LOCK)
+}
+
+// tryLockFile attempts to take an exclusive lock without blocking and
+// reports whether the lock was taken
+func tryLockFile(file *os.File) (bool, error) {
+	if err := lockFileEx(file, windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY); errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func unlockFile(file *os.File) error {
+	ol := &windows.Overlapped{OffsetHigh: lockRangeOffsetHigh}
+	return windows.UnlockFileEx(windows.Handle(file.Fd()), 0, 1, 0, ol)
+}
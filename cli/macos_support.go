@@ -273,18 +273,15 @@ func macOsGetInventory(dls vangogh_integration.ProductDownloadLinks, unpackDir s
 }
 
 func macOsPostInstallActions(id string,
-	ii *InstallInfo,
 	dls vangogh_integration.ProductDownloadLinks,
-	rdx redux.Readable,
-	unpackDir string,
+	unpackDir, absBundlePath string,
 	force bool) error {
 
 	mpia := nod.Begin(" running %s post-install actions for %s...", vangogh_integration.MacOS, id)
 	defer mpia.Done()
 
-	absBundlePaths := make(map[string]any)
-
 	installerPostInstallActed := false
+	postInstallActed := false
 
 	for _, link := range dls {
 
@@ -308,12 +305,7 @@ func macOsPostInstallActions(id string,
 			return err
 		}
 
-		absBundlePath, err := originOsInstalledPath(id, ii, rdx)
-		if err != nil {
-			return err
-		}
-
-		absBundlePaths[absBundlePath] = nil
+		postInstallActed = true
 
 		if customCommands := pis.CustomCommands(); len(customCommands) > 0 {
 			if err = macOsProcessPostInstallScript(customCommands, productDownloadsDir, absBundlePath); err != nil {
@@ -326,10 +318,8 @@ func macOsPostInstallActions(id string,
 		}
 	}
 
-	for absBundlePath := range absBundlePaths {
-		if err := macOsRemoveXattrs(absBundlePath); err != nil {
-			return err
-		}
+	if postInstallActed {
+		return macOsRemoveXattrs(absBundlePath)
 	}
 
 	return nil
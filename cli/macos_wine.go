@@ -61,7 +61,10 @@ func macOsWineExecTask(id string, et *execTask) error {
 		et.args = append([]string{"--workdir", et.workDir}, et.args...)
 	}
 
-	cmd := exec.Command(absWineBinPath, et.args...)
+	cmd, err := execTaskCommand(et, absWineBinPath, et.args...)
+	if err != nil {
+		return err
+	}
 
 	if et.workDir != "" {
 		cmd.Dir = et.workDir
@@ -69,12 +72,11 @@ func macOsWineExecTask(id string, et *execTask) error {
 
 	cmd.Env = et.env
 
-	if et.verbose {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+	if err = setExecTaskOutput(cmd, et); err != nil {
+		return err
 	}
 
-	return cmd.Run()
+	return runSupervised(cmd, et)
 }
 
 func macOsGetAbsCxBinDir(rdx redux.Readable) (string, error) {
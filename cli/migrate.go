@@ -36,7 +36,7 @@ func Migrate() error {
 		return err
 	}
 
-	newRdx, err := redux.NewWriter(data.AbsReduxDir(),
+	newRdx, err := newReduxWriter(
 		vangogh_integration.GogTitleProperty,
 		vangogh_integration.SteamTitleProperty,
 		vangogh_integration.EgsTitleProperty,
@@ -0,0 +1,423 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/pathways"
+	"github.com/boggydigital/redux"
+)
+
+func MoveHandler(u *url.URL) error {
+
+	q := u.Query()
+
+	id := q.Get(vangogh_integration.UrlIdParameter)
+
+	ii := &InstallInfo{
+		OperatingSystem: vangogh_integration.ParseOperatingSystem(q.Get(vangogh_integration.UrlOperatingSystemParameter)),
+		LangCode:        q.Get(vangogh_integration.UrlLanguageCodeParameter),
+		force:           q.Has(vangogh_integration.UrlForceParameter),
+	}
+
+	library, err := parseLibrary(q.Get(data.UrlLibraryParameter))
+	if err != nil {
+		return err
+	}
+
+	return Move(id, ii, library)
+}
+
+// Move relocates installed product and its prefix to another library. Files are
+// copied and verified before the installation location is updated and the
+// source files are removed, so an interrupted move leaves the installation intact
+func Move(id string, request *InstallInfo, library string) error {
+
+	ma := nod.Begin("moving %s...", id)
+	defer ma.Done()
+
+	rdx, err := newReduxWriter(data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	ii, err := matchInstalledInfo(id, request, rdx)
+	if err != nil {
+		return err
+	}
+
+	ii.force = request.force
+
+	if ii.Library == library {
+		ma.EndWithResult("already in the requested library")
+		return nil
+	}
+
+	if requiresGame, ok := rdx.GetLastVal(vangogh_integration.EgsMainGameProperty, id); ok && requiresGame != "" {
+		return errors.New("DLCs are moved with the main game " + requiresGame)
+	}
+
+	absLibraryRoot, err := data.AbsLibraryRoot(library, rdx)
+	if err != nil {
+		return err
+	}
+
+	title, err := data.GetTitleProperty(id, rdx)
+	if err != nil {
+		return err
+	}
+
+	if running, err := newRunState(id, title, ii).isRunning(); err != nil {
+		return err
+	} else if running {
+		return errors.New(title + " is running, please stop it before moving")
+	}
+
+	pl, err := lockProduct(id, ii, "moving")
+	if err != nil {
+		return err
+	}
+	defer pl.release()
+
+	movedIi := *ii
+	movedIi.Library = library
+
+	absSrcInstalledPath, err := originOsInstalledPath(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	absDstInstalledPath, err := originOsInstalledPath(id, &movedIi, rdx)
+	if err != nil {
+		return err
+	}
+
+	absSrcPrefixDir, err := data.AbsPrefixDir(id, ii.Origin, ii.Library, rdx)
+	if err != nil {
+		return err
+	}
+
+	absDstPrefixDir, err := data.AbsPrefixDir(id, movedIi.Origin, movedIi.Library, rdx)
+	if err != nil {
+		return err
+	}
+
+	if _, err = os.Stat(absSrcInstalledPath); err != nil {
+		return err
+	}
+
+	// prefix is optional, e.g. native installations don't have one
+	movePaths := map[string]string{absSrcInstalledPath: absDstInstalledPath}
+	if _, err = os.Stat(absSrcPrefixDir); err == nil {
+		if err = checkPrefixNotShared(id, ii, rdx); err != nil {
+			return err
+		}
+		movePaths[absSrcPrefixDir] = absDstPrefixDir
+	}
+
+	var totalBytes int64
+	for absSrcPath, absDstPath := range movePaths {
+
+		if _, err = os.Stat(absDstPath); err == nil && !ii.force {
+			return errors.New("destination already exists, use force to overwrite: " + absDstPath)
+		}
+
+		var bytes int64
+		if bytes, err = pathBytes(absSrcPath); err != nil {
+			return err
+		}
+		totalBytes += bytes
+	}
+
+	if err = mkdirLibraryDirs(absLibraryRoot); err != nil {
+		return err
+	}
+
+	if ok, err := hasFreeSpaceForBytes(absLibraryRoot, totalBytes); err != nil {
+		return err
+	} else if !ok && !ii.force {
+		return fmt.Errorf("not enough space for %s at %s", id, absLibraryRoot)
+	}
+
+	inventory, err := readInventoryEntries(id, ii.LangCode, ii.OperatingSystem, rdx)
+	if err != nil {
+		return err
+	}
+
+	// paths copied before a failure are removed, so that
+	// installation copy is not left without a prefix and vice versa
+	copiedPaths := make([]string, 0, len(movePaths))
+
+	for absSrcPath, absDstPath := range movePaths {
+
+		entries := inventory
+		if absSrcPath != absSrcInstalledPath || len(inventory) == 0 {
+			var relFiles []string
+			if relFiles, err = relWalkDir(absSrcPath); err != nil {
+				return removeCopiedPaths(err, copiedPaths, library, rdx)
+			}
+			entries = newInventoryEntries(relFiles...)
+		}
+
+		if err = copyVerify(absSrcPath, absDstPath, entries); err != nil {
+			return removeCopiedPaths(err, copiedPaths, library, rdx)
+		}
+
+		copiedPaths = append(copiedPaths, absDstPath)
+	}
+
+	if err = pinInstallInfo(id, &movedIi, rdx); err != nil {
+		return err
+	}
+
+	if err = moveEgsDlcsInstallInfo(id, ii, library, rdx); err != nil {
+		return err
+	}
+
+	if err = moveSteamShortcutsStartDir(absSrcInstalledPath, absDstInstalledPath); err != nil {
+		return err
+	}
+
+	for absSrcPath := range movePaths {
+		if err = removeMovedPath(absSrcPath, ii.Library, rdx); err != nil {
+			return err
+		}
+	}
+
+	ma.EndWithResult("moved to %s", absLibraryRoot)
+
+	return nil
+}
+
+func removeCopiedPaths(err error, absCopiedPaths []string, library string, rdx redux.Readable) error {
+	errs := []error{err}
+	for _, absCopiedPath := range absCopiedPaths {
+		errs = append(errs, removeMovedPath(absCopiedPath, library, rdx))
+	}
+	return errors.Join(errs...)
+}
+
+// checkPrefixNotShared makes sure that prefix, that is shared by all installations of
+// the product in the library, doesn't belong to another installation (e.g. another language)
+func checkPrefixNotShared(id string, ii *InstallInfo, rdx redux.Readable) error {
+
+	installedInfoLines, _ := rdx.GetAllValues(data.InstallInfoProperty, id)
+
+	installedInfos, err := unmarshalInstalledInfoLines(installedInfoLines...)
+	if err != nil {
+		return err
+	}
+
+	for _, installedInfo := range installedInfos {
+		if installedInfo.Origin == ii.Origin &&
+			installedInfo.Library == ii.Library &&
+			!installedInfo.Matches(ii) {
+			return fmt.Errorf("prefix of %s is shared with %s-%s installation, please uninstall or move it first",
+				id, installedInfo.OperatingSystem, installedInfo.LangCode)
+		}
+	}
+
+	return nil
+}
+
+// copyVerify copies source path to a temporary sibling of the destination and makes
+// sure that every file that exists in the source has been copied with the same
+// content. Verified copy replaces the destination, that is left intact if copying fails
+func copyVerify(absSrcPath, absDstPath string, entries []inventoryEntry) error {
+
+	cva := nod.Begin(" copying %s...", filepath.Base(absSrcPath))
+	defer cva.Done()
+
+	if err := os.MkdirAll(filepath.Dir(absDstPath), pathways.PermUrwGrwOr); err != nil {
+		return err
+	}
+
+	srcInfo, err := os.Stat(absSrcPath)
+	if err != nil {
+		return err
+	}
+
+	absTempPath, err := os.MkdirTemp(filepath.Dir(absDstPath), "."+filepath.Base(absDstPath)+"-")
+	if err != nil {
+		return err
+	}
+
+	if err = os.Chmod(absTempPath, srcInfo.Mode().Perm()); err != nil {
+		return errors.Join(err, os.RemoveAll(absTempPath))
+	}
+
+	if err = copyAll(absSrcPath, absTempPath); err != nil {
+		return errors.Join(err, os.RemoveAll(absTempPath))
+	}
+
+	if err = verifyCopiedFiles(absSrcPath, absTempPath, entries); err != nil {
+		return errors.Join(err, os.RemoveAll(absTempPath))
+	}
+
+	if err = os.RemoveAll(absDstPath); err != nil {
+		return errors.Join(err, os.RemoveAll(absTempPath))
+	}
+
+	if err = os.Rename(absTempPath, absDstPath); err != nil {
+		return errors.Join(err, os.RemoveAll(absTempPath))
+	}
+
+	cva.EndWithResult("verified %d files", len(entries))
+
+	return nil
+}
+
+// verifyCopiedFiles compares copied files checksums with the inventory, when available,
+// otherwise with the source files. Files modified since installation are compared with the source
+func verifyCopiedFiles(absSrcPath, absDstPath string, entries []inventoryEntry) error {
+
+	vcfa := nod.NewProgress(" verifying copied files...")
+	defer vcfa.Done()
+
+	vcfa.TotalInt(len(entries))
+
+	for _, entry := range entries {
+
+		absSrcFile := filepath.Join(absSrcPath, entry.Path)
+		absDstFile := filepath.Join(absDstPath, entry.Path)
+
+		srcInfo, err := os.Lstat(absSrcFile)
+		if os.IsNotExist(err) {
+			// files removed from the installation since the inventory was written
+			vcfa.Increment()
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		dstInfo, err := os.Lstat(absDstFile)
+		if err != nil {
+			return err
+		}
+
+		if srcInfo.Mode().Type() != dstInfo.Mode().Type() ||
+			(srcInfo.Mode().IsRegular() && srcInfo.Size() != dstInfo.Size()) {
+			return errors.New("copied file doesn't match the source: " + entry.Path)
+		}
+
+		if srcInfo.Mode().IsRegular() {
+
+			dstMd5, err := fileMd5(absDstFile)
+			if err != nil {
+				return err
+			}
+
+			if dstMd5 != entry.Md5 {
+				var srcMd5 string
+				if srcMd5, err = fileMd5(absSrcFile); err != nil {
+					return err
+				}
+				if dstMd5 != srcMd5 {
+					return errors.New("copied file checksum doesn't match the source: " + entry.Path)
+				}
+			}
+		}
+
+		vcfa.Increment()
+	}
+
+	return nil
+}
+
+// moveEgsDlcsInstallInfo updates library of the installed DLCs that share
+// the main game installation directory
+func moveEgsDlcsInstallInfo(id string, ii *InstallInfo, library string, rdx redux.Writeable) error {
+
+	if ii.Origin != data.EpicGamesOrigin {
+		return nil
+	}
+
+	for dlcId := range rdx.Keys(data.InstallInfoProperty) {
+
+		if mainGame, ok := rdx.GetLastVal(vangogh_integration.EgsMainGameProperty, dlcId); !ok || mainGame != id {
+			continue
+		}
+
+		dlcIi, err := matchInstalledInfo(dlcId, ii, rdx)
+		if errors.Is(err, ErrInstallInfoNotFound) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		dlcIi.Library = library
+
+		if err = pinInstallInfo(dlcId, dlcIi, rdx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeMovedPath removes source path and any of its parent
+// directories that were left empty in the source library
+func removeMovedPath(absSrcPath string, library string, rdx redux.Readable) error {
+
+	if err := os.RemoveAll(absSrcPath); err != nil {
+		return err
+	}
+
+	absLibraryRoot, err := data.AbsLibraryRoot(library, rdx)
+	if err != nil {
+		return err
+	}
+
+	relDir, err := filepath.Rel(absLibraryRoot, filepath.Dir(absSrcPath))
+	if err != nil {
+		return err
+	}
+
+	// library directories and their top level directories (e.g. installed-apps/macOS-en,
+	// wine/_prefixes) are kept, only emptied product directories are removed
+	for strings.Count(relDir, string(filepath.Separator)) > 1 {
+
+		absDir := filepath.Join(absLibraryRoot, relDir)
+
+		if entries, err := os.ReadDir(absDir); err != nil || len(entries) > 0 {
+			return nil
+		}
+
+		if err = os.Remove(absDir); err != nil {
+			return err
+		}
+
+		relDir = filepath.Dir(relDir)
+	}
+
+	return nil
+}
+
+func pathBytes(absPath string) (int64, error) {
+
+	var bytes int64
+
+	err := filepath.WalkDir(absPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			var info fs.FileInfo
+			if info, err = d.Info(); err != nil {
+				return err
+			}
+			bytes += info.Size()
+		}
+		return nil
+	})
+
+	return bytes, err
+}
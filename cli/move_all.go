@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/boggydigital/pathways"
+)
+
+// moveAll renames source path to destination path. Library roots can be
+// on different volumes, where rename is not possible - in that case
+// files are copied and the source is removed after successful copy
+func moveAll(absSrcPath, absDstPath string) error {
+
+	err := os.Rename(absSrcPath, absDstPath)
+	if !errors.Is(err, errCrossDevice) {
+		return err
+	}
+
+	if err = copyAll(absSrcPath, absDstPath); err != nil {
+		return errors.Join(err, os.RemoveAll(absDstPath))
+	}
+
+	return os.RemoveAll(absSrcPath)
+}
+
+// copyAll copies source file or directory to destination path,
+// preserving symlinks, permissions and modification times
+func copyAll(absSrcPath, absDstPath string) error {
+
+	return filepath.WalkDir(absSrcPath, func(path string, d fs.DirEntry, err error) error {
+
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(absSrcPath, path)
+		if err != nil {
+			return err
+		}
+
+		absDstFilename := filepath.Join(absDstPath, relPath)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			var target string
+			if target, err = os.Readlink(path); err != nil {
+				return err
+			}
+			return os.Symlink(target, absDstFilename)
+		case d.IsDir():
+			return os.MkdirAll(absDstFilename, info.Mode().Perm())
+		default:
+			return copyFile(path, absDstFilename, info)
+		}
+	})
+}
+
+func copyFile(absSrcFilename, absDstFilename string, info fs.FileInfo) error {
+
+	if err := os.MkdirAll(filepath.Dir(absDstFilename), pathways.PermUrwGrwOr); err != nil {
+		return err
+	}
+
+	srcFile, err := os.Open(absSrcFilename)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.OpenFile(absDstFilename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(dstFile, srcFile); err != nil {
+		return errors.Join(err, dstFile.Close())
+	}
+
+	if err = dstFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Chtimes(absDstFilename, info.ModTime(), info.ModTime())
+}
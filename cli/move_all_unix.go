@@ -0,0 +1,8 @@
+//go:build !windows
+
+package cli
+
+import "syscall"
+
+// errCrossDevice is returned by rename across volumes
+var errCrossDevice = syscall.EXDEV
@@ -0,0 +1,8 @@
+//go:build windows
+
+package cli
+
+import "golang.org/x/sys/windows"
+
+// errCrossDevice is returned by rename across volumes
+var errCrossDevice = windows.ERROR_NOT_SAME_DEVICE
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"encoding/json/jsontext"
+	"encoding/json/v2"
+	"io"
+	"net/url"
+	"os"
+
+	"github.com/arelate/theo/data"
+)
+
+const (
+	TextFormat = "text"
+	JsonFormat = "json"
+	CsvFormat  = "csv"
+)
+
+func OutputFormats() []string {
+	return []string{TextFormat, JsonFormat}
+}
+
+// ExportFormats are supported by commands that export tabular data
+func ExportFormats() []string {
+	return []string{TextFormat, JsonFormat, CsvFormat}
+}
+
+func IsJsonFormat(u *url.URL) bool {
+	if u == nil {
+		return false
+	}
+	return u.Query().Get(data.UrlFormatParameter) == JsonFormat
+}
+
+func IsCsvFormat(u *url.URL) bool {
+	if u == nil {
+		return false
+	}
+	return u.Query().Get(data.UrlFormatParameter) == CsvFormat
+}
+
+// jsonOutput is replaced by serve to capture output of API jobs,
+// it's also used for other machine-readable output, e.g. CSV
+var jsonOutput io.Writer = os.Stdout
+
+func writeJson(v any) error {
+	return json.MarshalEncode(jsontext.NewEncoder(jsonOutput, jsontext.WithIndent("  ")), v)
+}
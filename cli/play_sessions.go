@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json/v2"
+	"strings"
+	"time"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/redux"
+)
+
+type playSession struct {
+	Id              string                              `json:"id"`
+	Title           string                              `json:"title,omitempty"`
+	Start           time.Time                           `json:"start"`
+	End             time.Time                           `json:"end"`
+	DurationSeconds int64                               `json:"duration-seconds"`
+	Origin          data.Origin                         `json:"origin"`
+	OperatingSystem vangogh_integration.OperatingSystem `json:"os"`
+	LangCode        string                              `json:"lang-code"`
+	ExitStatus      string                              `json:"exit-status"`
+}
+
+func (ps *playSession) duration() time.Duration {
+	return time.Duration(ps.DurationSeconds) * time.Second
+}
+
+func recordPlaySession(rdx redux.Writeable, id string, ii *InstallInfo, start, end time.Time, runErr error) error {
+
+	if err := rdx.MustHave(data.PlaySessionsProperty); err != nil {
+		return err
+	}
+
+	ps := &playSession{
+		Id:              id,
+		Start:           start.UTC(),
+		End:             end.UTC(),
+		DurationSeconds: int64(end.Sub(start).Seconds()),
+		Origin:          ii.Origin,
+		OperatingSystem: ii.OperatingSystem,
+		LangCode:        ii.LangCode,
+		ExitStatus:      runExitStatus(runErr),
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := json.MarshalWrite(buf, ps); err != nil {
+		return err
+	}
+
+	return rdx.AddValues(data.PlaySessionsProperty, id, buf.String())
+}
+
+// getPlaySessions returns play sessions of all products when no ids are specified
+func getPlaySessions(rdx redux.Readable, ids ...string) ([]*playSession, error) {
+
+	if err := rdx.MustHave(data.PlaySessionsProperty); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		for id := range rdx.Keys(data.PlaySessionsProperty) {
+			ids = append(ids, id)
+		}
+	}
+
+	playSessions := make([]*playSession, 0)
+
+	for _, id := range ids {
+
+		playSessionsLines, ok := rdx.GetAllValues(data.PlaySessionsProperty, id)
+		if !ok {
+			continue
+		}
+
+		title, err := data.GetTitleProperty(id, rdx)
+		if err != nil {
+			title = id
+		}
+
+		for _, line := range playSessionsLines {
+
+			var ps playSession
+			if err = json.UnmarshalRead(strings.NewReader(line), &ps); err != nil {
+				return nil, err
+			}
+
+			ps.Title = title
+
+			playSessions = append(playSessions, &ps)
+		}
+	}
+
+	return playSessions, nil
+}
@@ -54,12 +54,17 @@ func PrefixHandler(u *url.URL) error {
 	program := q.Get(vangogh_integration.UrlProgramParameter)
 	installBinary := q.Get(vangogh_integration.UrlInstallBinaryParameter)
 
-	return Prefix(id, ii, mod, program, installBinary, et)
+	backup := q.Has(data.UrlBackupParameter)
+	restore := q.Has(data.UrlRestoreParameter)
+	snapshot := q.Get(data.UrlSnapshotParameter)
+	list := q.Has(vangogh_integration.UrlListParameter)
+
+	return Prefix(id, ii, mod, program, installBinary, backup, restore, snapshot, list, et)
 }
 
-func Prefix(id string, request *InstallInfo, mod, program, wineBinary string, et *execTask) error {
+func Prefix(id string, request *InstallInfo, mod, program, wineBinary string, backup, restore bool, snapshot string, list bool, et *execTask) error {
 
-	rdx, err := redux.NewWriter(data.AbsReduxDir(), data.AllProperties()...)
+	rdx, err := newReduxWriter(data.AllProperties()...)
 	if err != nil {
 		return err
 	}
@@ -69,13 +74,32 @@ func Prefix(id string, request *InstallInfo, mod, program, wineBinary string, et
 		return err
 	}
 
-	absPrefixDir, err := data.AbsPrefixDir(id, ii.Origin, rdx)
+	absPrefixDir, err := data.AbsPrefixDir(id, ii.Origin, ii.Library, rdx)
 	if err != nil {
 		return err
 	}
 
 	et.prefix = absPrefixDir
 
+	// backup is created before restore, so that current state can be restored later
+	if backup {
+		if err = prefixBackup(id, ii, rdx); err != nil {
+			return err
+		}
+	}
+
+	if restore {
+		if err = prefixRestore(id, ii, snapshot, rdx); err != nil {
+			return err
+		}
+	}
+
+	if list {
+		if err = prefixListSnapshots(id, ii, rdx); err != nil {
+			return err
+		}
+	}
+
 	if et.exe != "" {
 		et.title = filepath.Base(et.exe)
 		return osExec(id, vangogh_integration.Windows, et)
@@ -85,11 +109,11 @@ func Prefix(id string, request *InstallInfo, mod, program, wineBinary string, et
 
 		switch mod {
 		case prefixModEnableRetina:
-			if err = prefixModRetina(id, ii.Origin, false, rdx, et.verbose, ii.force); err != nil {
+			if err = prefixModRetina(id, ii, false, rdx, et.verbose, ii.force); err != nil {
 				return err
 			}
 		case prefixModDisableRetina:
-			if err = prefixModRetina(id, ii.Origin, true, rdx, et.verbose, ii.force); err != nil {
+			if err = prefixModRetina(id, ii, true, rdx, et.verbose, ii.force); err != nil {
 				return err
 			}
 		}
@@ -188,7 +212,7 @@ func prefixInstallBinary(id string, wineBinary string, absPrefixDir string, et *
 	return osExec(id, vangogh_integration.Windows, et)
 }
 
-func prefixModRetina(id string, origin data.Origin, revert bool, rdx redux.Writeable, verbose, force bool) error {
+func prefixModRetina(id string, ii *InstallInfo, revert bool, rdx redux.Writeable, verbose, force bool) error {
 
 	mpa := nod.Begin("modding retina in prefix for %s...", id)
 	defer mpa.Done()
@@ -198,7 +222,7 @@ func prefixModRetina(id string, origin data.Origin, revert bool, rdx redux.Write
 		return nil
 	}
 
-	absPrefixDir, err := data.AbsPrefixDir(id, origin, rdx)
+	absPrefixDir, err := data.AbsPrefixDir(id, ii.Origin, ii.Library, rdx)
 	if err != nil {
 		return err
 	}
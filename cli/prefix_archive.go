@@ -0,0 +1,383 @@
+package cli
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/backups"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/pathways"
+	"github.com/boggydigital/redux"
+)
+
+const (
+	tarGzExt           = ".tar.gz"
+	restoringPrefixExt = ".restoring"
+	previousPrefixExt  = ".previous"
+)
+
+func prefixBackup(id string, ii *InstallInfo, rdx redux.Readable) error {
+
+	pba := nod.Begin("backing up prefix for %s...", id)
+	defer pba.Done()
+
+	absPrefixDir, err := data.AbsPrefixDir(id, ii.Origin, ii.Library, rdx)
+	if err != nil {
+		return err
+	}
+
+	if _, err = os.Stat(absPrefixDir); os.IsNotExist(err) {
+		return errors.New("prefix not found for " + id)
+	}
+
+	absPrefixArchiveDir, err := data.AbsPrefixArchiveDir(id, ii.Origin, rdx)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(absPrefixArchiveDir, pathways.PermUrwGrwOr); err != nil {
+		return err
+	}
+
+	snapshot := backups.Filename()
+
+	if err = compressPrefix(absPrefixDir, filepath.Join(absPrefixArchiveDir, snapshot)); err != nil {
+		return err
+	}
+
+	ca := nod.NewProgress("cleaning up old prefix backups...")
+	defer ca.Done()
+
+	if err = backups.Cleanup(absPrefixArchiveDir, true, ca); err != nil {
+		return err
+	}
+
+	pba.EndWithResult("created snapshot: %s", strings.TrimSuffix(snapshot, tarGzExt))
+
+	return nil
+}
+
+// prefixRestore replaces prefix with the contents of a snapshot, the latest
+// snapshot is restored when none is specified. Existing prefix is only removed
+// after the snapshot has been extracted successfully
+func prefixRestore(id string, ii *InstallInfo, snapshot string, rdx redux.Readable) error {
+
+	pra := nod.Begin("restoring prefix for %s...", id)
+	defer pra.Done()
+
+	absPrefixArchiveDir, err := data.AbsPrefixArchiveDir(id, ii.Origin, rdx)
+	if err != nil {
+		return err
+	}
+
+	snapshots, err := archiveSnapshots(absPrefixArchiveDir)
+	if err != nil {
+		return err
+	}
+
+	if snapshot, err = selectSnapshot(snapshots, snapshot); err != nil {
+		return err
+	}
+
+	pl, err := lockProduct(id, ii, "restoring prefix")
+	if err != nil {
+		return err
+	}
+	defer pl.release()
+
+	absPrefixDir, err := data.AbsPrefixDir(id, ii.Origin, ii.Library, rdx)
+	if err != nil {
+		return err
+	}
+
+	absRestoringDir := absPrefixDir + restoringPrefixExt
+	absPreviousDir := absPrefixDir + previousPrefixExt
+
+	for _, dir := range []string{absRestoringDir, absPreviousDir} {
+		if err = os.RemoveAll(dir); err != nil {
+			return err
+		}
+	}
+
+	if err = extractArchive(filepath.Join(absPrefixArchiveDir, snapshot+tarGzExt), absRestoringDir); err != nil {
+		return errors.Join(err, os.RemoveAll(absRestoringDir))
+	}
+
+	var hadPrefix bool
+	if _, err = os.Stat(absPrefixDir); err == nil {
+		if err = os.Rename(absPrefixDir, absPreviousDir); err != nil {
+			return err
+		}
+		hadPrefix = true
+	}
+
+	if err = os.Rename(absRestoringDir, absPrefixDir); err != nil {
+		if hadPrefix {
+			return errors.Join(err, os.Rename(absPreviousDir, absPrefixDir))
+		}
+		return err
+	}
+
+	if err = os.RemoveAll(absPreviousDir); err != nil {
+		return err
+	}
+
+	pra.EndWithResult("restored snapshot: %s", snapshot)
+
+	return nil
+}
+
+func prefixListSnapshots(id string, ii *InstallInfo, rdx redux.Readable) error {
+
+	plsa := nod.Begin("listing prefix backups for %s...", id)
+	defer plsa.Done()
+
+	absPrefixArchiveDir, err := data.AbsPrefixArchiveDir(id, ii.Origin, rdx)
+	if err != nil {
+		return err
+	}
+
+	snapshots, err := archiveSnapshots(absPrefixArchiveDir)
+	if err != nil {
+		return err
+	}
+
+	if len(snapshots) == 0 {
+		plsa.EndWithResult("no backups found")
+		return nil
+	}
+
+	summary, err := snapshotsSummary(absPrefixArchiveDir, snapshots)
+	if err != nil {
+		return err
+	}
+
+	plsa.EndWithSummary("found the following backups:", summary)
+
+	return nil
+}
+
+// archiveSnapshots returns sorted snapshots names, oldest first
+func archiveSnapshots(absDir string) ([]string, error) {
+
+	entries, err := os.ReadDir(absDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), tarGzExt) {
+			continue
+		}
+		snapshots = append(snapshots, strings.TrimSuffix(entry.Name(), tarGzExt))
+	}
+
+	slices.Sort(snapshots)
+
+	return snapshots, nil
+}
+
+// selectSnapshot returns the latest snapshot when none is specified,
+// otherwise makes sure specified snapshot is available
+func selectSnapshot(snapshots []string, snapshot string) (string, error) {
+
+	if len(snapshots) == 0 {
+		return "", errors.New("no backups found")
+	}
+
+	switch snapshot {
+	case "":
+		return snapshots[len(snapshots)-1], nil
+	default:
+		snapshot = strings.TrimSuffix(filepath.Base(snapshot), tarGzExt)
+		if !slices.Contains(snapshots, snapshot) {
+			return "", errors.New("snapshot " + snapshot + " not found")
+		}
+		return snapshot, nil
+	}
+}
+
+// snapshotsSummary reports creation date and archive size of every snapshot
+func snapshotsSummary(absDir string, snapshots []string) (map[string][]string, error) {
+
+	summary := make(map[string][]string)
+
+	for _, snapshot := range snapshots {
+
+		var infoLines []string
+
+		if st, err := time.ParseInLocation(nod.TimeFormat, snapshot, time.Local); err == nil {
+			infoLines = append(infoLines, "date: "+st.Format(time.DateTime))
+		}
+
+		if fi, err := os.Stat(filepath.Join(absDir, snapshot+tarGzExt)); err == nil {
+			infoLines = append(infoLines, "size: "+vangogh_integration.FormatBytes(fi.Size()))
+		} else {
+			return nil, err
+		}
+
+		summary[snapshot] = infoLines
+	}
+
+	return summary, nil
+}
+
+// compressPrefix archives prefix directory, unlike backups.Compress it preserves
+// directories and symlinks, as WINE prefix drives are symlinked in dosdevices
+func compressPrefix(absPrefixDir, absArchiveFilename string) error {
+
+	cpa := nod.Begin(" compressing prefix...")
+	defer cpa.Done()
+
+	archiveFile, err := os.Create(absArchiveFilename)
+	if err != nil {
+		return err
+	}
+
+	gw := gzip.NewWriter(archiveFile)
+	tw := tar.NewWriter(gw)
+
+	walkErr := filepath.Walk(absPrefixDir, func(path string, fi fs.FileInfo, err error) error {
+
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(absPrefixDir, path)
+		if err != nil {
+			return err
+		}
+
+		if relPath == "." {
+			return nil
+		}
+
+		var link string
+		if fi.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		} else if !fi.Mode().IsDir() && !fi.Mode().IsRegular() {
+			// sockets, pipes and devices can't be restored
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relPath)
+
+		if err = tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(tw, file)
+		return err
+	})
+
+	err = errors.Join(walkErr, tw.Close(), gw.Close(), archiveFile.Close())
+	if err != nil {
+		return errors.Join(err, os.Remove(absArchiveFilename))
+	}
+
+	return nil
+}
+
+// extractArchive extracts tar.gz archive into a destination directory,
+// entries that would be placed outside of that directory are rejected
+func extractArchive(absArchiveFilename, absDstDir string) error {
+
+	eaa := nod.Begin(" extracting %s...", filepath.Base(absArchiveFilename))
+	defer eaa.Done()
+
+	archiveFile, err := os.Open(absArchiveFilename)
+	if err != nil {
+		return err
+	}
+	defer archiveFile.Close()
+
+	gr, err := gzip.NewReader(archiveFile)
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+
+	if err = os.MkdirAll(absDstDir, pathways.PermUrwGrwOr); err != nil {
+		return err
+	}
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		relPath := filepath.FromSlash(header.Name)
+		if !filepath.IsLocal(relPath) {
+			return errors.New("unsafe path in archive: " + header.Name)
+		}
+
+		absPath := filepath.Join(absDstDir, relPath)
+
+		if err = os.MkdirAll(filepath.Dir(absPath), pathways.PermUrwGrwOr); err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err = os.MkdirAll(absPath, header.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err = os.Symlink(header.Linkname, absPath); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err = extractArchiveFile(tr, absPath, header.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		default:
+			// do nothing
+		}
+	}
+}
+
+func extractArchiveFile(r io.Reader, absPath string, perm os.FileMode) error {
+
+	file, err := os.OpenFile(absPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, r)
+	return err
+}
@@ -24,12 +24,12 @@ const lnkGlob = "*.lnk"
 
 const prefixRelDriveCDir = "drive_c"
 
-func prefixInit(id string, origin data.Origin, rdx redux.Readable, verbose bool) error {
+func prefixInit(id string, ii *InstallInfo, rdx redux.Readable, verbose bool) error {
 
 	cpa := nod.Begin("initializing prefix for %s...", id)
 	defer cpa.Done()
 
-	absPrefixDir, err := data.AbsPrefixDir(id, origin, rdx)
+	absPrefixDir, err := data.AbsPrefixDir(id, ii.Origin, ii.Library, rdx)
 	if err != nil {
 		return err
 	}
@@ -44,8 +44,8 @@ func prefixInit(id string, origin data.Origin, rdx redux.Readable, verbose bool)
 	}
 }
 
-func prefixTempUnpackDir(id string, origin data.Origin, rdx redux.Readable) (string, error) {
-	absPrefixDir, err := data.AbsPrefixDir(id, origin, rdx)
+func prefixTempUnpackDir(id string, ii *InstallInfo, rdx redux.Readable) (string, error) {
+	absPrefixDir, err := data.AbsPrefixDir(id, ii.Origin, ii.Library, rdx)
 	if err != nil {
 		return "", err
 	}
@@ -59,7 +59,7 @@ func prefixRunInstallers(id string, ii *InstallInfo, dls vangogh_integration.Pro
 
 	downloadsDir := data.Pwd.AbsDirPath(data.Downloads)
 
-	absPrefixDir, err := data.AbsPrefixDir(id, ii.Origin, rdx)
+	absPrefixDir, err := data.AbsPrefixDir(id, ii.Origin, ii.Library, rdx)
 	if err != nil {
 		return err
 	}
@@ -31,7 +31,7 @@ func RemoveDownloadsHandler(u *url.URL) error {
 		force:           q.Has(vangogh_integration.UrlForceParameter),
 	}
 
-	rdx, err := redux.NewWriter(data.AbsReduxDir(), data.AllProperties()...)
+	rdx, err := newReduxWriter(data.AllProperties()...)
 	if err != nil {
 		return err
 	}
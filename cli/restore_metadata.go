@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+const previousMetadataDirname = "_previous"
+
+func RestoreMetadataHandler(u *url.URL) error {
+
+	q := u.Query()
+
+	snapshot := q.Get(data.UrlSnapshotParameter)
+	list := q.Has(vangogh_integration.UrlListParameter)
+	validate := q.Has(vangogh_integration.UrlValidateParameter)
+
+	return RestoreMetadata(snapshot, list, validate)
+}
+
+// RestoreMetadata replaces local metadata with the contents of a backup-metadata
+// archive, the latest archive is restored when none is specified. Archive is
+// extracted and validated before the current metadata is replaced
+func RestoreMetadata(snapshot string, list, validate bool) error {
+
+	backupsDir := data.Pwd.AbsDirPath(data.Backups)
+
+	snapshots, err := archiveSnapshots(backupsDir)
+	if err != nil {
+		return err
+	}
+
+	if list {
+		return listMetadataBackups(backupsDir, snapshots)
+	}
+
+	rma := nod.Begin("restoring local metadata...")
+	defer rma.Done()
+
+	if snapshot, err = selectSnapshot(snapshots, snapshot); err != nil {
+		return err
+	}
+
+	absRestoreDir := filepath.Join(data.Pwd.AbsRelDirPath(data.MetadataRestores, data.Temp), snapshot)
+
+	if err = extractValidateMetadata(filepath.Join(backupsDir, snapshot+tarGzExt), absRestoreDir); err != nil {
+		return errors.Join(err, os.RemoveAll(absRestoreDir))
+	}
+
+	if validate {
+		rma.EndWithResult("snapshot %s is valid", snapshot)
+		return os.RemoveAll(absRestoreDir)
+	}
+
+	// current metadata is backed up, so that restore can be reverted with another restore
+	if err = BackupMetadata(); err != nil {
+		return errors.Join(err, os.RemoveAll(absRestoreDir))
+	}
+
+	if err = swapMetadata(absRestoreDir); err != nil {
+		return err
+	}
+
+	rma.EndWithResult("restored snapshot: %s", snapshot)
+
+	return nil
+}
+
+func listMetadataBackups(backupsDir string, snapshots []string) error {
+
+	lmba := nod.Begin("listing local metadata backups...")
+	defer lmba.Done()
+
+	if len(snapshots) == 0 {
+		lmba.EndWithResult("no backups found")
+		return nil
+	}
+
+	summary, err := snapshotsSummary(backupsDir, snapshots)
+	if err != nil {
+		return err
+	}
+
+	lmba.EndWithSummary("found the following backups:", summary)
+
+	return nil
+}
+
+// extractValidateMetadata extracts archive, which validates archive integrity,
+// and makes sure that extracted redux store can be opened
+func extractValidateMetadata(absArchiveFilename, absRestoreDir string) error {
+
+	evma := nod.Begin(" validating %s...", filepath.Base(absArchiveFilename))
+	defer evma.Done()
+
+	if err := os.RemoveAll(absRestoreDir); err != nil {
+		return err
+	}
+
+	if err := extractArchive(absArchiveFilename, absRestoreDir); err != nil {
+		return err
+	}
+
+	absReduxDir := filepath.Join(absRestoreDir, string(data.Redux))
+
+	if _, err := os.Stat(absReduxDir); os.IsNotExist(err) {
+		return errors.New("backup doesn't contain redux store")
+	}
+
+	if _, err := redux.NewReader(absReduxDir, data.AllProperties()...); err != nil {
+		return errors.Join(errors.New("backup redux store can't be opened"), err)
+	}
+
+	return nil
+}
+
+// swapMetadata replaces metadata directory with the restored one, holding the redux
+// lock to prevent other theo processes from writing metadata at the same time.
+// Lock files are kept outside of metadata directory, so they're not affected
+func swapMetadata(absRestoreDir string) error {
+
+	sma := nod.Begin(" replacing local metadata...")
+	defer sma.Done()
+
+	rl, err := acquireLock(reduxLockName, "restoring metadata", waitForLocks.Load(), reduxLockGracePeriod)
+	if err != nil {
+		return err
+	}
+	defer rl.release()
+
+	absMetadataDir := data.Pwd.AbsDirPath(data.Metadata)
+	absPreviousDir := filepath.Join(filepath.Dir(absRestoreDir), previousMetadataDirname)
+
+	if err = os.RemoveAll(absPreviousDir); err != nil {
+		return err
+	}
+
+	if err = os.Rename(absMetadataDir, absPreviousDir); err != nil {
+		return err
+	}
+
+	if err = os.Rename(absRestoreDir, absMetadataDir); err != nil {
+		return errors.Join(err, os.Rename(absPreviousDir, absMetadataDir))
+	}
+
+	return os.RemoveAll(absPreviousDir)
+}
@@ -77,12 +77,13 @@ func revealInstalled(id string, request *InstallInfo) error {
 	ria := nod.Begin("revealing installation for %s...", id)
 	defer ria.Done()
 
-	rdx, err := redux.NewReader(data.AbsReduxDir(),
+	rdx, err := newReduxReader(
 		vangogh_integration.GogTitleProperty,
 		vangogh_integration.SteamTitleProperty,
 		vangogh_integration.EgsTitleProperty,
 		vangogh_integration.GogBundleNameProperty,
-		data.InstallInfoProperty)
+		data.InstallInfoProperty,
+		data.LibraryRootsProperty)
 	if err != nil {
 		return err
 	}
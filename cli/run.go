@@ -3,10 +3,14 @@ package cli
 import (
 	"errors"
 	"net/url"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/arelate/southern_light/gog_integration"
 	"github.com/arelate/southern_light/vangogh_integration"
@@ -28,8 +32,16 @@ type execTask struct {
 	steamProtonRuntime string
 	prefix             string
 	task               string
+	profile            string
 	defaultLauncher    bool
 	verbose            bool
+	wrappers           []string
+	preHooks           []string
+	postHooks          []string
+	runLog             *os.File
+	runState           *runState
+	// runLock is held from the running check until the run state is written
+	runLock *fileLock
 }
 
 func RunHandler(u *url.URL) error {
@@ -58,6 +70,7 @@ func RunHandler(u *url.URL) error {
 		workDir:         q.Get(vangogh_integration.UrlWorkDirParameter),
 		verbose:         q.Has(vangogh_integration.UrlVerboseParameter),
 		task:            q.Get(vangogh_integration.UrlTaskParameter),
+		profile:         q.Get(data.UrlProfileParameter),
 		defaultLauncher: q.Has(vangogh_integration.UrlDefaultLauncherParameter),
 	}
 
@@ -96,7 +109,7 @@ func Run(id string, request *InstallInfo, et *execTask) error {
 	ra := nod.NewProgress("running product %s...", id)
 	defer ra.Done()
 
-	rdx, err := redux.NewWriter(data.AbsReduxDir(), data.AllProperties()...)
+	rdx, err := newReduxWriter(data.AllProperties()...)
 	if err != nil {
 		return err
 	}
@@ -127,6 +140,10 @@ func Run(id string, request *InstallInfo, et *execTask) error {
 		false,
 		false)
 
+	if err = applyLaunchProfileTask(id, ii, et, rdx); err != nil {
+		return err
+	}
+
 	if err = setLastRunDate(rdx, id); err != nil {
 		return err
 	}
@@ -144,11 +161,55 @@ func Run(id string, request *InstallInfo, et *execTask) error {
 		return err
 	}
 
-	if err = osExec(id, ii.OperatingSystem, et); err != nil {
+	title, err := data.GetTitleProperty(id, rdx)
+	if err != nil {
+		return err
+	}
+
+	if et.runLock, err = lockProduct(id, ii, "starting"); err != nil {
+		return err
+	}
+	defer et.releaseRunLock()
+
+	et.runState = newRunState(id, title, ii)
+
+	if running, err := et.runState.isRunning(); err != nil {
 		return err
+	} else if running {
+		return errors.New(title + " is already running, use status and stop commands to manage it")
+	}
+
+	if err = runLaunchHooks(preLaunchHook, et.preHooks, id, ii, et, nil, rdx); err != nil {
+		return err
+	}
+
+	if et.runLog, err = createRunLog(id, ii, et); err != nil {
+		return err
+	}
+
+	execStart := time.Now()
+
+	runErr := osExec(id, ii.OperatingSystem, et)
+
+	execEnd := time.Now()
+
+	if err = closeRunLog(et.runLog, runErr); err != nil {
+		return errors.Join(runErr, err)
+	}
+
+	if err = recordPlaySession(rdx, id, ii, execStart, execEnd, runErr); err != nil {
+		return errors.Join(runErr, err)
 	}
 
-	playSessionDuration := time.Since(playSessionStart)
+	if err = runLaunchHooks(postExitHook, et.postHooks, id, ii, et, runErr, rdx); err != nil {
+		return errors.Join(runErr, err)
+	}
+
+	if runErr != nil {
+		return runErr
+	}
+
+	playSessionDuration := execEnd.Sub(playSessionStart)
 
 	if err = recordPlaytime(rdx, id, playSessionDuration); err != nil {
 		return err
@@ -419,6 +480,19 @@ func osExecTaskDefaultLauncher(absDefaultLauncherPath string, operatingSystem va
 	return et, nil
 }
 
+// releaseRunLock releases the run lock, if it's still held
+func (et *execTask) releaseRunLock() error {
+
+	if et.runLock == nil {
+		return nil
+	}
+
+	rl := et.runLock
+	et.runLock = nil
+
+	return rl.release()
+}
+
 func osExec(id string, operatingSystem vangogh_integration.OperatingSystem, et *execTask) error {
 
 	switch operatingSystem {
@@ -441,6 +515,82 @@ func osExec(id string, operatingSystem vangogh_integration.OperatingSystem, et *
 	}
 }
 
+// execTaskCommand creates a command prefixed with the chain of launch wrappers,
+// e.g. wrappers "gamescope -w 1920 -h 1080 --" and "mangohud" would result in
+// gamescope -w 1920 -h 1080 -- mangohud name args...
+func execTaskCommand(et *execTask, name string, args ...string) (*exec.Cmd, error) {
+
+	var chain []string
+	for _, wrapper := range et.wrappers {
+		wrapperArgs, err := splitWrapper(wrapper)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, wrapperArgs...)
+	}
+
+	if len(chain) == 0 {
+		return exec.Command(name, args...), nil
+	}
+
+	return exec.Command(chain[0], slices.Concat(chain[1:], []string{name}, args)...), nil
+}
+
+// splitWrapper splits wrapper into arguments the way a shell would, so that
+// quoted arguments can contain spaces, e.g. sh -c 'taskset -c 0-3 "$@"' --
+func splitWrapper(wrapper string) ([]string, error) {
+
+	var args []string
+	var arg strings.Builder
+	var quote rune
+	inArg, escaped := false, false
+
+	for _, r := range wrapper {
+		switch {
+		case escaped:
+			arg.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inArg = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote or escape in wrapper: " + wrapper)
+	}
+
+	if inArg {
+		args = append(args, arg.String())
+	}
+
+	return args, nil
+}
+
 func windowsToNixPath(wp string) string {
 	return strings.Replace(wp, "\\", "/", -1)
 }
@@ -450,11 +600,16 @@ func osApplyLaunchOptions(id string, ii *InstallInfo, et *execTask, rdx redux.Re
 	if err := rdx.MustHave(
 		data.LaunchOptionsExeProperty,
 		data.LaunchOptionsArgProperty,
-		data.LaunchOptionsEnvProperty); err != nil {
+		data.LaunchOptionsEnvProperty,
+		data.LaunchOptionsWrapperProperty,
+		data.LaunchOptionsPreHookProperty,
+		data.LaunchOptionsPostHookProperty,
+		data.LaunchOptionsProtonRuntimeProperty,
+		data.LaunchOptionsProtonOptionProperty); err != nil {
 		return err
 	}
 
-	appOsLangCode := data.AppOsLangCode(id, ii.OperatingSystem, ii.LangCode)
+	appOsLangCode := data.LaunchOptionsKey(id, ii.OperatingSystem, ii.LangCode, et.profile)
 
 	if exe, ok := rdx.GetLastVal(data.LaunchOptionsExeProperty, appOsLangCode); ok && exe != "" {
 		et.exe = exe
@@ -468,5 +623,50 @@ func osApplyLaunchOptions(id string, ii *InstallInfo, et *execTask, rdx redux.Re
 		et.env = append(et.env, env...)
 	}
 
+	if wrappers, ok := rdx.GetAllValues(data.LaunchOptionsWrapperProperty, appOsLangCode); ok && len(wrappers) > 0 {
+		et.wrappers = wrappers
+	}
+
+	if preHooks, ok := rdx.GetAllValues(data.LaunchOptionsPreHookProperty, appOsLangCode); ok && len(preHooks) > 0 {
+		et.preHooks = preHooks
+	}
+
+	if postHooks, ok := rdx.GetAllValues(data.LaunchOptionsPostHookProperty, appOsLangCode); ok && len(postHooks) > 0 {
+		et.postHooks = postHooks
+	}
+
+	// proton runtime provided for the run takes precedence over launch options
+	if protonRuntime, ok := rdx.GetLastVal(data.LaunchOptionsProtonRuntimeProperty, appOsLangCode); ok && protonRuntime != "" && et.protonRuntime == "" {
+		et.protonRuntime = protonRuntime
+	}
+
+	if protonOptions, ok := rdx.GetAllValues(data.LaunchOptionsProtonOptionProperty, appOsLangCode); ok && len(protonOptions) > 0 {
+		et.protonOptions = append(et.protonOptions, protonOptions...)
+	}
+
+	return nil
+}
+
+// applyLaunchProfileTask sets launch profile task before the exec task is resolved,
+// as the task selects the executable. Task provided for the run takes precedence
+func applyLaunchProfileTask(id string, ii *InstallInfo, et *execTask, rdx redux.Readable) error {
+
+	if err := rdx.MustHave(data.LaunchOptionsProfilesProperty, data.LaunchOptionsTaskProperty); err != nil {
+		return err
+	}
+
+	if et.profile != "" && et.profile != data.DefaultLaunchProfile &&
+		!rdx.HasValue(data.LaunchOptionsProfilesProperty, data.AppOsLangCode(id, ii.OperatingSystem, ii.LangCode), et.profile) {
+		return errors.New("launch options profile not found: " + et.profile)
+	}
+
+	if et.task != "" {
+		return nil
+	}
+
+	if task, ok := rdx.GetLastVal(data.LaunchOptionsTaskProperty, data.LaunchOptionsKey(id, ii.OperatingSystem, ii.LangCode, et.profile)); ok {
+		et.task = task
+	}
+
 	return nil
 }
@@ -0,0 +1,305 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/pathways"
+)
+
+const (
+	runLogExt            = ".log"
+	runLogsToPreserve    = 10
+	runLogOutputMarker   = "--- output ---"
+	runLogExitCodePrefix = "exit code: "
+	runLogTailSize       = 4 * 1024
+	runLogWaitDelay      = 5 * time.Second
+)
+
+func LogsHandler(u *url.URL) error {
+
+	q := u.Query()
+
+	id := q.Get(vangogh_integration.UrlIdParameter)
+
+	ii := &InstallInfo{
+		OperatingSystem: vangogh_integration.ParseOperatingSystem(q.Get(vangogh_integration.UrlOperatingSystemParameter)),
+		LangCode:        q.Get(vangogh_integration.UrlLanguageCodeParameter),
+	}
+
+	list := q.Has(vangogh_integration.UrlListParameter)
+	run := q.Get(data.UrlRunParameter)
+
+	return Logs(id, ii, list, run)
+}
+
+// Logs prints the log of a product run, the latest run is printed when none is specified
+func Logs(id string, request *InstallInfo, list bool, run string) error {
+
+	la := nod.Begin("getting run logs for %s...", id)
+	defer la.Done()
+
+	rdx, err := newReduxReader(data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	ii, err := matchInstalledInfo(id, request, rdx)
+	if err != nil {
+		return err
+	}
+
+	absRunLogsDir := data.AbsRunLogsDir(id, ii.Origin, ii.OperatingSystem, ii.LangCode)
+
+	runs, err := runLogs(absRunLogsDir)
+	if err != nil {
+		return err
+	}
+
+	if len(runs) == 0 {
+		la.EndWithResult("no run logs found")
+		return nil
+	}
+
+	if list {
+		return listRunLogs(absRunLogsDir, runs)
+	}
+
+	switch run {
+	case "":
+		run = runs[len(runs)-1]
+	default:
+		run = strings.TrimSuffix(run, runLogExt)
+		if !slices.Contains(runs, run) {
+			return errors.New("run log " + run + " not found")
+		}
+	}
+
+	la.EndWithResult("run: %s", run)
+
+	runLogFile, err := os.Open(filepath.Join(absRunLogsDir, run+runLogExt))
+	if err != nil {
+		return err
+	}
+	defer runLogFile.Close()
+
+	_, err = io.Copy(os.Stdout, runLogFile)
+	return err
+}
+
+func listRunLogs(absRunLogsDir string, runs []string) error {
+
+	lrla := nod.Begin("listing run logs...")
+	defer lrla.Done()
+
+	summary := make(map[string][]string)
+
+	for _, run := range runs {
+
+		var infoLines []string
+
+		if rt, err := time.ParseInLocation(nod.TimeFormat, run, time.Local); err == nil {
+			infoLines = append(infoLines, "date: "+rt.Format(time.DateTime))
+		}
+
+		exitCode, err := runLogExitCode(filepath.Join(absRunLogsDir, run+runLogExt))
+		if err != nil {
+			return err
+		}
+
+		if exitCode != "" {
+			infoLines = append(infoLines, runLogExitCodePrefix+exitCode)
+		}
+
+		summary[run] = infoLines
+	}
+
+	lrla.EndWithSummary("found the following runs:", summary)
+
+	return nil
+}
+
+// runLogs returns sorted run logs names, oldest first
+func runLogs(absRunLogsDir string) ([]string, error) {
+
+	entries, err := os.ReadDir(absRunLogsDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	runs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), runLogExt) {
+			continue
+		}
+		runs = append(runs, strings.TrimSuffix(entry.Name(), runLogExt))
+	}
+
+	slices.Sort(runs)
+
+	return runs, nil
+}
+
+// runLogExitCode reads exit code recorded at the end of the run log
+func runLogExitCode(absRunLogFilename string) (string, error) {
+
+	runLogFile, err := os.Open(absRunLogFilename)
+	if err != nil {
+		return "", err
+	}
+	defer runLogFile.Close()
+
+	stat, err := runLogFile.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	if _, err = runLogFile.Seek(max(0, stat.Size()-runLogTailSize), io.SeekStart); err != nil {
+		return "", err
+	}
+
+	tail, err := io.ReadAll(runLogFile)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(tail)), "\n")
+	if lastLine := lines[len(lines)-1]; strings.HasPrefix(lastLine, runLogExitCodePrefix) {
+		return strings.TrimPrefix(lastLine, runLogExitCodePrefix), nil
+	}
+
+	return "", nil
+}
+
+// createRunLog creates a new run log for a product with the resolved exec task
+// details and removes the oldest run logs over the preserved number
+func createRunLog(id string, ii *InstallInfo, et *execTask) (*os.File, error) {
+
+	absRunLogsDir := data.AbsRunLogsDir(id, ii.Origin, ii.OperatingSystem, ii.LangCode)
+
+	if err := os.MkdirAll(absRunLogsDir, pathways.PermUrwGrwOr); err != nil {
+		return nil, err
+	}
+
+	runs, err := runLogs(absRunLogsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(runs) >= runLogsToPreserve {
+		for _, run := range runs[:len(runs)-runLogsToPreserve+1] {
+			if err = os.Remove(filepath.Join(absRunLogsDir, run+runLogExt)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	runLogFile, err := os.Create(filepath.Join(absRunLogsDir, time.Now().Format(nod.TimeFormat)+runLogExt))
+	if err != nil {
+		return nil, err
+	}
+
+	header := [][2]string{
+		{"id", id},
+		{"origin", ii.Origin.String()},
+		{"os", ii.OperatingSystem.String()},
+		{"lang-code", ii.LangCode},
+		{"title", et.title},
+		{"exe", et.exe},
+		{"work-dir", et.workDir},
+		{"args", strings.Join(et.args, " ")},
+		{"env", strings.Join(et.env, " ")},
+		{"prefix", et.prefix},
+		{"proton-runtime", et.protonRuntime},
+		{"steam-proton-runtime", et.steamProtonRuntime},
+		{"proton-options", strings.Join(et.protonOptions, " ")},
+	}
+
+	for _, kv := range header {
+		if kv[1] == "" {
+			continue
+		}
+		if _, err = fmt.Fprintf(runLogFile, "%s: %s\n", kv[0], kv[1]); err != nil {
+			return nil, errors.Join(err, runLogFile.Close())
+		}
+	}
+
+	return runLogFile, nil
+}
+
+// closeRunLog records exit code of the run and closes the run log
+func closeRunLog(runLogFile *os.File, runErr error) error {
+
+	if _, err := fmt.Fprintf(runLogFile, "\n%s%s\n", runLogExitCodePrefix, runExitStatus(runErr)); err != nil {
+		return errors.Join(err, runLogFile.Close())
+	}
+
+	return runLogFile.Close()
+}
+
+// runExitStatus returns exit code of the run, or the error
+// when the process couldn't be started or waited for
+func runExitStatus(runErr error) string {
+
+	var exitErr *exec.ExitError
+
+	switch {
+	case runErr == nil:
+		return "0"
+	case errors.As(runErr, &exitErr):
+		return strconv.Itoa(exitErr.ExitCode())
+	default:
+		return runErr.Error()
+	}
+}
+
+// setExecTaskOutput directs command output to the terminal in verbose mode
+// and to the run log when it's available
+func setExecTaskOutput(cmd *exec.Cmd, et *execTask) error {
+
+	var stdout, stderr []io.Writer
+
+	if et.verbose {
+		stdout = append(stdout, os.Stdout)
+		stderr = append(stderr, os.Stderr)
+	}
+
+	if et.runLog != nil {
+		if _, err := fmt.Fprintf(et.runLog, "command: %s\n%s\n", strings.Join(cmd.Args, " "), runLogOutputMarker); err != nil {
+			return err
+		}
+		stdout = append(stdout, et.runLog)
+		stderr = append(stderr, et.runLog)
+	}
+
+	switch len(stdout) {
+	case 0:
+		// do nothing
+	case 1:
+		// files are passed to the process directly, without a copying goroutine
+		cmd.Stdout = stdout[0]
+		cmd.Stderr = stderr[0]
+	default:
+		// processes started by the command might keep the output pipes open
+		// after it exits, so don't wait for them indefinitely
+		cmd.Stdout = io.MultiWriter(stdout...)
+		cmd.Stderr = io.MultiWriter(stderr...)
+		cmd.WaitDelay = runLogWaitDelay
+	}
+
+	return nil
+}
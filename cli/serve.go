@@ -0,0 +1,645 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json/jsontext"
+	"encoding/json/v2"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/nod"
+)
+
+const (
+	defaultServePort = 1854
+	serveOutput      = "serve"
+	serveSocketName  = "theo.sock"
+	serveTokenName   = "token"
+	maxFinishedJobs  = 100
+	maxJobEvents     = 1000
+)
+
+type jobStatus string
+
+const (
+	jobQueued    jobStatus = "queued"
+	jobRunning   jobStatus = "running"
+	jobSucceeded jobStatus = "succeeded"
+	jobFailed    jobStatus = "failed"
+)
+
+var serveHandlers = map[string]func(u *url.URL) error{
+	"install":   InstallHandler,
+	"list":      ListHandler,
+	"run":       RunHandler,
+	"uninstall": UninstallHandler,
+	"update":    UpdateHandler,
+	"validate":  ValidateHandler,
+}
+
+type jobEvent struct {
+	Type    string `json:"type"`
+	Topic   string `json:"topic"`
+	Payload string `json:"payload,omitempty"`
+}
+
+type job struct {
+	Id          int            `json:"id"`
+	Command     string         `json:"command"`
+	Query       string         `json:"query,omitempty"`
+	Status      jobStatus      `json:"status"`
+	Error       string         `json:"error,omitempty"`
+	Output      jsontext.Value `json:"output,omitzero"`
+	TimeQueued  string         `json:"time-queued"`
+	TimeStarted string         `json:"time-started,omitempty"`
+	TimeEnded   string         `json:"time-ended,omitempty"`
+	output      bytes.Buffer
+	events      []*jobEvent
+	// number of the oldest events dropped over maxJobEvents
+	droppedEvents int
+	updated       chan struct{}
+}
+
+// detachedCommands last until the product exits, so instead of blocking the queue
+// they're run by separate theo processes. Their progress is not reported as job events
+var detachedCommands = []string{"run"}
+
+// jobQueue runs jobs one at a time, so that commands never
+// race each other on the shared metadata store
+type jobQueue struct {
+	mtx     sync.Mutex
+	nextId  int
+	jobs    map[int]*job
+	current *job
+	pending chan *job
+	// per-topic state used to throttle progress events
+	totals   map[string]uint64
+	percents map[string]uint64
+}
+
+func newJobQueue() *jobQueue {
+	return &jobQueue{
+		nextId:   1,
+		jobs:     make(map[int]*job),
+		pending:  make(chan *job, 1024),
+		totals:   make(map[string]uint64),
+		percents: make(map[string]uint64),
+	}
+}
+
+func ServeHandler(u *url.URL) error {
+
+	q := u.Query()
+
+	port := defaultServePort
+	if q.Has(vangogh_integration.UrlPortParameter) {
+		var err error
+		if port, err = strconv.Atoi(q.Get(vangogh_integration.UrlPortParameter)); err != nil {
+			return err
+		}
+	}
+
+	// unix socket is used unless TCP port is requested explicitly
+	socket := q.Get(data.UrlSocketParameter)
+	if socket == "" && !q.Has(vangogh_integration.UrlPortParameter) {
+		socket = filepath.Join(data.Pwd.AbsRelDirPath(data.Serve, data.Temp), serveSocketName)
+	}
+
+	return Serve(port, socket)
+}
+
+func Serve(port int, socket string) error {
+
+	sa := nod.Begin("serving theo API...")
+	defer sa.Done()
+
+	var err error
+
+	jq := newJobQueue()
+
+	nod.HandleFunc(jq, serveOutput)
+
+	// jobs share one redux handle, see newReduxWriter
+	if sharedRdx, err = newReduxWriter(data.AllProperties()...); err != nil {
+		return err
+	}
+	defer func() { sharedRdx = nil }()
+
+	// jobs run one at a time, so their output is captured by the queue
+	jsonOutput = jq
+	defer func() { jsonOutput = os.Stdout }()
+
+	var listener net.Listener
+	var token string
+
+	switch socket {
+	case "":
+		if token, err = writeServeToken(); err != nil {
+			return err
+		}
+		defer removeServeToken()
+		listener, err = net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	default:
+		if err = os.Remove(socket); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if listener, err = net.Listen("unix", socket); err == nil {
+			err = os.Chmod(socket, 0600)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/{command}", jq.postJob)
+	mux.HandleFunc("GET /api/jobs", jq.getJobs)
+	mux.HandleFunc("GET /api/jobs/{id}", jq.getJob)
+	mux.HandleFunc("GET /api/jobs/{id}/events", jq.getJobEvents)
+
+	server := &http.Server{Handler: authorizeLocal(mux, token)}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go jq.work(ctx)
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if serr := server.Shutdown(shutdownCtx); serr != nil {
+			sa.Error(serr)
+		}
+	}()
+
+	switch token {
+	case "":
+		sa.EndWithResult("listening on %s", listener.Addr())
+	default:
+		sa.EndWithResult("listening on %s, requests require bearer token from %s",
+			listener.Addr(), absServeTokenPath())
+	}
+
+	if err = server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+func (jq *jobQueue) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-jq.pending:
+			jq.runJob(j)
+		}
+	}
+}
+
+func (jq *jobQueue) runJob(j *job) {
+
+	jq.mtx.Lock()
+	j.Status = jobRunning
+	j.TimeStarted = time.Now().Format(time.RFC3339)
+	jq.current = j
+	clear(jq.totals)
+	clear(jq.percents)
+	jq.notify(j)
+	jq.mtx.Unlock()
+
+	err := runServeHandler(j)
+
+	jq.mtx.Lock()
+	defer jq.mtx.Unlock()
+
+	jq.current = nil
+	j.TimeEnded = time.Now().Format(time.RFC3339)
+	if j.output.Len() > 0 {
+		j.Output = bytes.TrimSpace(j.output.Bytes())
+		j.output.Reset()
+	}
+
+	switch err {
+	case nil:
+		j.Status = jobSucceeded
+	default:
+		j.Status = jobFailed
+		j.Error = err.Error()
+	}
+
+	jq.notify(j)
+}
+
+// runDetached runs the job command in a separate theo process
+func (jq *jobQueue) runDetached(j *job) {
+
+	jq.mtx.Lock()
+	j.Status = jobRunning
+	j.TimeStarted = time.Now().Format(time.RFC3339)
+	jq.notify(j)
+	jq.mtx.Unlock()
+
+	stderr := new(bytes.Buffer)
+
+	theoExe, err := os.Executable()
+	if err == nil {
+		cmd := exec.Command(theoExe, detachedArgs(j)...)
+		cmd.Stderr = stderr
+		err = cmd.Run()
+	}
+
+	jq.mtx.Lock()
+	defer jq.mtx.Unlock()
+
+	j.TimeEnded = time.Now().Format(time.RFC3339)
+
+	switch err {
+	case nil:
+		j.Status = jobSucceeded
+	default:
+		j.Status = jobFailed
+		j.Error = err.Error()
+		if lines := strings.Split(strings.TrimSpace(stderr.String()), "\n"); lines[len(lines)-1] != "" {
+			j.Error = lines[len(lines)-1]
+		}
+	}
+
+	jq.notify(j)
+}
+
+// detachedArgs converts job query into command line arguments
+func detachedArgs(j *job) []string {
+
+	args := []string{j.Command}
+
+	q, err := url.ParseQuery(j.Query)
+	if err != nil {
+		return args
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(q)) {
+		args = append(args, "-"+key)
+		for _, value := range q[key] {
+			if value != "" {
+				args = append(args, value)
+			}
+		}
+	}
+
+	return args
+}
+
+func runServeHandler(j *job) (err error) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s panicked: %v", j.Command, r)
+		}
+	}()
+
+	u := &url.URL{Path: j.Command, RawQuery: j.Query}
+	SetWaitForLocks(u)
+
+	return serveHandlers[j.Command](u)
+}
+
+// notify wakes up event streams for the job, must be called with mtx locked
+func (jq *jobQueue) notify(j *job) {
+	close(j.updated)
+	j.updated = make(chan struct{})
+}
+
+func (jq *jobQueue) Handle(msgType nod.MessageType, payload any, topic string) {
+
+	jq.mtx.Lock()
+	defer jq.mtx.Unlock()
+
+	if jq.current == nil {
+		return
+	}
+
+	event := &jobEvent{
+		Type:  msgType.String(),
+		Topic: topic,
+	}
+
+	switch msgType {
+	case nod.MsgTotal:
+		if total, ok := payload.(uint64); ok {
+			jq.totals[topic] = total
+		}
+		return
+	case nod.MsgCurrent:
+		// only report progress when percentage changes
+		current, ok := payload.(uint64)
+		total := jq.totals[topic]
+		if !ok || total == 0 {
+			return
+		}
+		percent := current * 100 / total
+		if prevPercent, sure := jq.percents[topic]; sure && prevPercent == percent {
+			return
+		}
+		jq.percents[topic] = percent
+		event.Payload = strconv.FormatUint(percent, 10) + "%"
+	default:
+		if payload != nil {
+			event.Payload = fmt.Sprint(payload)
+		}
+	}
+
+	jq.current.events = append(jq.current.events, event)
+	if len(jq.current.events) > maxJobEvents {
+		jq.current.events = jq.current.events[1:]
+		jq.current.droppedEvents++
+	}
+	jq.notify(jq.current)
+}
+
+// Write captures JSON output of the current job
+func (jq *jobQueue) Write(p []byte) (int, error) {
+
+	jq.mtx.Lock()
+	defer jq.mtx.Unlock()
+
+	if jq.current == nil {
+		return len(p), nil
+	}
+
+	return jq.current.output.Write(p)
+}
+
+func (jq *jobQueue) Close() error {
+	return nil
+}
+
+func (jq *jobQueue) postJob(w http.ResponseWriter, r *http.Request) {
+
+	command := r.PathValue("command")
+	if _, ok := serveHandlers[command]; !ok {
+		http.Error(w, "unsupported command "+command, http.StatusNotFound)
+		return
+	}
+
+	jq.mtx.Lock()
+
+	j := &job{
+		Id:         jq.nextId,
+		Command:    command,
+		Query:      r.URL.RawQuery,
+		Status:     jobQueued,
+		TimeQueued: time.Now().Format(time.RFC3339),
+		updated:    make(chan struct{}),
+	}
+
+	jq.jobs[j.Id] = j
+	jq.nextId++
+	jq.pruneJobs()
+
+	if slices.Contains(detachedCommands, command) {
+		go jq.runDetached(j)
+		jq.mtx.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		jq.writeJob(w, j)
+		return
+	}
+
+	select {
+	case jq.pending <- j:
+	default:
+		delete(jq.jobs, j.Id)
+		jq.mtx.Unlock()
+		http.Error(w, "job queue is full", http.StatusServiceUnavailable)
+		return
+	}
+
+	jq.mtx.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	jq.writeJob(w, j)
+}
+
+func (jq *jobQueue) getJobs(w http.ResponseWriter, _ *http.Request) {
+
+	jq.mtx.Lock()
+	defer jq.mtx.Unlock()
+
+	jobs := make([]*job, 0, len(jq.jobs))
+	for _, id := range slices.Sorted(maps.Keys(jq.jobs)) {
+		jobs = append(jobs, jq.jobs[id])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.MarshalWrite(w, jobs); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (jq *jobQueue) getJob(w http.ResponseWriter, r *http.Request) {
+
+	j, err := jq.pathJob(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	jq.writeJob(w, j)
+}
+
+func (jq *jobQueue) writeJob(w http.ResponseWriter, j *job) {
+
+	jq.mtx.Lock()
+	defer jq.mtx.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.MarshalWrite(w, j); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (jq *jobQueue) getJobEvents(w http.ResponseWriter, r *http.Request) {
+
+	j, err := jq.pathJob(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	// sent counts all events of the job, including the dropped ones
+	var sent int
+
+	for {
+		jq.mtx.Lock()
+		events := slices.Clone(j.events[max(sent-j.droppedEvents, 0):])
+		sent = j.droppedEvents + len(j.events)
+		status := j.Status
+		updated := j.updated
+		jq.mtx.Unlock()
+
+		for _, event := range events {
+			if err = writeSse(w, "progress", event); err != nil {
+				return
+			}
+		}
+
+		if status == jobSucceeded || status == jobFailed {
+			jq.mtx.Lock()
+			err = writeSse(w, "end", j)
+			jq.mtx.Unlock()
+			if err == nil {
+				flusher.Flush()
+			}
+			return
+		}
+
+		flusher.Flush()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-updated:
+		}
+	}
+}
+
+// pruneJobs removes the oldest finished jobs over maxFinishedJobs,
+// must be called with mtx locked
+func (jq *jobQueue) pruneJobs() {
+
+	var finished []int
+	for _, id := range slices.Sorted(maps.Keys(jq.jobs)) {
+		if status := jq.jobs[id].Status; status == jobSucceeded || status == jobFailed {
+			finished = append(finished, id)
+		}
+	}
+
+	for len(finished) > maxFinishedJobs {
+		delete(jq.jobs, finished[0])
+		finished = finished[1:]
+	}
+}
+
+func (jq *jobQueue) pathJob(r *http.Request) (*job, error) {
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return nil, err
+	}
+
+	jq.mtx.Lock()
+	defer jq.mtx.Unlock()
+
+	if j, ok := jq.jobs[id]; ok {
+		return j, nil
+	}
+
+	return nil, errors.New("job not found")
+}
+
+func writeSse(w http.ResponseWriter, event string, v any) error {
+
+	bts, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, bts)
+	return err
+}
+
+func absServeTokenPath() string {
+	return filepath.Join(data.Pwd.AbsRelDirPath(data.Serve, data.Temp), serveTokenName)
+}
+
+// writeServeToken creates a new token for this serve instance, only readable by the user
+func writeServeToken() (string, error) {
+
+	token := rand.Text()
+
+	if err := os.WriteFile(absServeTokenPath(), []byte(token), 0600); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func removeServeToken() {
+	if err := os.Remove(absServeTokenPath()); err != nil && !os.IsNotExist(err) {
+		nod.LogError(err)
+	}
+}
+
+func isLocalHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	switch host {
+	case "localhost":
+		return true
+	default:
+		ip := net.ParseIP(host)
+		return ip != nil && ip.IsLoopback()
+	}
+}
+
+// authorizeLocal rejects browser requests from other origins, requests with a
+// non-local Host (DNS rebinding) and, when token is set, requests without it
+func authorizeLocal(next http.Handler, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if origin := r.Header.Get("Origin"); origin != "" {
+			if ou, err := url.Parse(origin); err != nil || !isLocalHost(ou.Host) {
+				http.Error(w, "origin is not allowed", http.StatusForbidden)
+				return
+			}
+		}
+
+		if token != "" {
+			if !isLocalHost(r.Host) {
+				http.Error(w, "host is not allowed", http.StatusForbidden)
+				return
+			}
+			bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+				http.Error(w, "missing or invalid token", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
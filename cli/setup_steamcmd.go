@@ -29,7 +29,7 @@ func SetupSteamCmd(force bool) error {
 	ssca := nod.Begin("setting up SteamCMD for %s...", currentOs)
 	defer ssca.Done()
 
-	rdx, err := redux.NewWriter(data.AbsReduxDir(), data.VangoghProperties()...)
+	rdx, err := newReduxWriter(data.VangoghProperties()...)
 	if err != nil {
 		return err
 	}
@@ -48,7 +48,7 @@ func SetupWine(force bool) error {
 
 	properties := append(data.VangoghProperties(), data.WineBinariesVersionsProperty)
 
-	rdx, err := redux.NewWriter(data.AbsReduxDir(), properties...)
+	rdx, err := newReduxWriter(properties...)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package cli
+
+import "os/exec"
+
+// shellCommand runs hook command with the system shell
+func shellCommand(command string) *exec.Cmd {
+	return exec.Command("sh", "-c", command)
+}
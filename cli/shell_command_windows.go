@@ -0,0 +1,16 @@
+//go:build windows
+
+package cli
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// shellCommand runs hook command with the system shell. Command line is set
+// as is, since cmd doesn't follow the quoting rules exec.Command escapes arguments with
+func shellCommand(command string) *exec.Cmd {
+	cmd := exec.Command("cmd")
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: `cmd /S /C "` + command + `"`}
+	return cmd
+}
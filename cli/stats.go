@@ -0,0 +1,279 @@
+package cli
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/nod"
+)
+
+const (
+	statsDays       = 7
+	statsWeeks      = 8
+	statsMonths     = 12
+	statsMostPlayed = 10
+)
+
+const (
+	dayLayout   = time.DateOnly
+	monthLayout = "2006-01"
+)
+
+type namedPlaytime struct {
+	Name    string `json:"name"`
+	Minutes int64  `json:"minutes"`
+}
+
+type playStats struct {
+	Days              []namedPlaytime `json:"days"`
+	Weeks             []namedPlaytime `json:"weeks"`
+	Months            []namedPlaytime `json:"months"`
+	CurrentStreakDays int             `json:"current-streak-days"`
+	LongestStreakDays int             `json:"longest-streak-days"`
+	MostPlayed        []namedPlaytime `json:"most-played"`
+	Origins           []namedPlaytime `json:"origins"`
+	Sessions          []*playSession  `json:"sessions"`
+}
+
+func StatsHandler(u *url.URL) error {
+
+	q := u.Query()
+
+	var ids []string
+	if q.Has(vangogh_integration.UrlIdParameter) {
+		ids = strings.Split(q.Get(vangogh_integration.UrlIdParameter), ",")
+	}
+
+	format := q.Get(data.UrlFormatParameter)
+
+	return Stats(ids, format)
+}
+
+// Stats reports playtime based on the recorded play sessions, all products
+// are reported when no ids are specified
+func Stats(ids []string, format string) error {
+
+	sa := nod.Begin("computing play stats...")
+	defer sa.Done()
+
+	rdx, err := newReduxReader(data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	playSessions, err := getPlaySessions(rdx, ids...)
+	if err != nil {
+		return err
+	}
+
+	slices.SortFunc(playSessions, func(a, b *playSession) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	switch format {
+	case CsvFormat:
+		return writePlaySessionsCsv(playSessions)
+	default:
+		// do nothing
+	}
+
+	ps := computePlayStats(playSessions, time.Now())
+
+	switch format {
+	case JsonFormat:
+		return writeJson(ps)
+	default:
+		// do nothing
+	}
+
+	if len(playSessions) == 0 {
+		sa.EndWithResult("no play sessions recorded")
+		return nil
+	}
+
+	summary := map[string][]string{
+		"playtime per day:":    formatNamedPlaytimes(ps.Days),
+		"playtime per week:":   formatNamedPlaytimes(ps.Weeks),
+		"playtime per month:":  formatNamedPlaytimes(ps.Months),
+		"playtime per origin:": formatNamedPlaytimes(ps.Origins),
+		"most played:":         formatNamedPlaytimes(ps.MostPlayed),
+		"streaks:": {
+			"current: " + strconv.Itoa(ps.CurrentStreakDays) + " day(s)",
+			"longest: " + strconv.Itoa(ps.LongestStreakDays) + " day(s)",
+		},
+	}
+
+	sa.EndWithSummary(fmt.Sprintf("play stats for %d session(s):", len(playSessions)), summary)
+
+	return nil
+}
+
+func computePlayStats(playSessions []*playSession, now time.Time) *playStats {
+
+	dayPlaytime := make(map[string]time.Duration)
+	weekPlaytime := make(map[string]time.Duration)
+	monthPlaytime := make(map[string]time.Duration)
+	titlePlaytime := make(map[string]time.Duration)
+	originPlaytime := make(map[string]time.Duration)
+
+	for _, ps := range playSessions {
+		start := ps.Start.Local()
+		dayPlaytime[start.Format(dayLayout)] += ps.duration()
+		weekPlaytime[isoWeek(start)] += ps.duration()
+		monthPlaytime[start.Format(monthLayout)] += ps.duration()
+		titlePlaytime[ps.Title] += ps.duration()
+		originPlaytime[ps.Origin.String()] += ps.duration()
+	}
+
+	stats := &playStats{
+		Sessions: playSessions,
+	}
+
+	for ii := statsDays - 1; ii >= 0; ii-- {
+		day := now.AddDate(0, 0, -ii).Format(dayLayout)
+		stats.Days = append(stats.Days, newNamedPlaytime(day, dayPlaytime[day]))
+	}
+
+	for ii := statsWeeks - 1; ii >= 0; ii-- {
+		week := isoWeek(now.AddDate(0, 0, -7*ii))
+		stats.Weeks = append(stats.Weeks, newNamedPlaytime(week, weekPlaytime[week]))
+	}
+
+	for ii := statsMonths - 1; ii >= 0; ii-- {
+		month := time.Date(now.Year(), now.Month()-time.Month(ii), 1, 0, 0, 0, 0, now.Location()).Format(monthLayout)
+		stats.Months = append(stats.Months, newNamedPlaytime(month, monthPlaytime[month]))
+	}
+
+	stats.MostPlayed = sortedNamedPlaytimes(titlePlaytime)
+	if len(stats.MostPlayed) > statsMostPlayed {
+		stats.MostPlayed = stats.MostPlayed[:statsMostPlayed]
+	}
+
+	stats.Origins = sortedNamedPlaytimes(originPlaytime)
+
+	stats.CurrentStreakDays, stats.LongestStreakDays = playStreaks(slices.Collect(maps.Keys(dayPlaytime)), now)
+
+	return stats
+}
+
+// playStreaks returns the number of consecutive days played up until today (or yesterday,
+// as today's session might not have happened yet) and the longest number of consecutive days played
+func playStreaks(days []string, now time.Time) (int, int) {
+
+	playedDays := make(map[string]any, len(days))
+	for _, day := range days {
+		playedDays[day] = nil
+	}
+
+	slices.Sort(days)
+
+	var longest, streak int
+	var prevDay time.Time
+
+	for _, day := range days {
+		dt, err := time.ParseInLocation(dayLayout, day, now.Location())
+		if err != nil {
+			continue
+		}
+
+		if !prevDay.IsZero() && prevDay.AddDate(0, 0, 1).Format(dayLayout) == day {
+			streak++
+		} else {
+			streak = 1
+		}
+
+		longest = max(longest, streak)
+		prevDay = dt
+	}
+
+	var current int
+
+	day := now
+	if _, ok := playedDays[day.Format(dayLayout)]; !ok {
+		day = day.AddDate(0, 0, -1)
+	}
+
+	for {
+		if _, ok := playedDays[day.Format(dayLayout)]; !ok {
+			break
+		}
+		current++
+		day = day.AddDate(0, 0, -1)
+	}
+
+	return current, longest
+}
+
+func isoWeek(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+func newNamedPlaytime(name string, playtime time.Duration) namedPlaytime {
+	return namedPlaytime{
+		Name:    name,
+		Minutes: int64(playtime.Minutes()),
+	}
+}
+
+// sortedNamedPlaytimes returns named playtimes, longest first
+func sortedNamedPlaytimes(playtimes map[string]time.Duration) []namedPlaytime {
+
+	nps := make([]namedPlaytime, 0, len(playtimes))
+	for name, playtime := range playtimes {
+		nps = append(nps, newNamedPlaytime(name, playtime))
+	}
+
+	slices.SortFunc(nps, func(a, b namedPlaytime) int {
+		if c := cmp.Compare(b.Minutes, a.Minutes); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return nps
+}
+
+func formatNamedPlaytimes(nps []namedPlaytime) []string {
+
+	lines := make([]string, 0, len(nps))
+	for _, np := range nps {
+		lines = append(lines, fmt.Sprintf("%s: %dh %02dm", np.Name, np.Minutes/60, np.Minutes%60))
+	}
+
+	return lines
+}
+
+func writePlaySessionsCsv(playSessions []*playSession) error {
+
+	cw := csv.NewWriter(jsonOutput)
+
+	records := [][]string{
+		{"id", "title", "start", "end", "duration-seconds", "origin", "os", "lang-code", "exit-status"},
+	}
+
+	for _, ps := range playSessions {
+		records = append(records, []string{
+			ps.Id,
+			ps.Title,
+			ps.Start.Format(time.RFC3339),
+			ps.End.Format(time.RFC3339),
+			strconv.FormatInt(ps.DurationSeconds, 10),
+			ps.Origin.String(),
+			ps.OperatingSystem.String(),
+			ps.LangCode,
+			ps.ExitStatus,
+		})
+	}
+
+	return cw.WriteAll(records)
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boggydigital/nod"
+)
+
+func StatusHandler(u *url.URL) error {
+	return Status(IsJsonFormat(u))
+}
+
+// Status reports products that are currently running
+func Status(jsonFormat bool) error {
+
+	sa := nod.Begin("checking running products...")
+	defer sa.Done()
+
+	runStates, err := getRunStates()
+	if err != nil {
+		return err
+	}
+
+	slices.SortFunc(runStates, func(a, b *runState) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	if jsonFormat {
+		return writeJson(runStates)
+	}
+
+	if len(runStates) == 0 {
+		sa.EndWithResult("no products are running")
+		return nil
+	}
+
+	summary := make(map[string][]string)
+
+	for _, rs := range runStates {
+
+		heading := rs.Title + " (" + rs.Origin.String() + ": " + rs.Id + ")"
+
+		summary[heading] = []string{
+			strings.Join([]string{"os: " + rs.OperatingSystem.String(), "lang: " + rs.LangCode}, "; "),
+			"started: " + rs.Start.Local().Format(time.DateTime),
+			"running for: " + time.Since(rs.Start).Round(time.Second).String(),
+			"process group: " + strconv.Itoa(rs.Pgid),
+		}
+	}
+
+	sa.EndWithSummary("running products:", summary)
+
+	return nil
+}
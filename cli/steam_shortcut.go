@@ -157,7 +157,7 @@ func SteamShortcutHandler(u *url.URL) error {
 
 func SteamShortcut(id, forId string, ii *InstallInfo, sgo *steamGridOptions, remove bool) error {
 
-	rdx, err := redux.NewWriter(data.AbsReduxDir(), data.AllProperties()...)
+	rdx, err := newReduxWriter(data.AllProperties()...)
 	if err != nil {
 		return err
 	}
@@ -654,3 +654,59 @@ func removeNonSteamAppShortcut(shortcutId uint32, kvUserShortcuts steam_vdf.Valv
 
 	return true, nil
 }
+
+// moveSteamShortcutsStartDir rewrites StartDir of the shortcuts created for the moved installation
+func moveSteamShortcutsStartDir(absSrcPath, absDstPath string) error {
+	mssa := nod.Begin("updating Steam shortcuts start dir...")
+	defer mssa.Done()
+
+	ok, err := steamStateDirExist()
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		mssa.EndWithResult("Steam state dir not found")
+		return nil
+	}
+
+	loginUsers, err := getSteamLoginUsers()
+	if err != nil {
+		return err
+	}
+
+	quotedSrcPath := strings.Join([]string{"\"", absSrcPath, "\""}, "")
+	quotedDstPath := strings.Join([]string{"\"", absDstPath, "\""}, "")
+
+	for _, loginUser := range loginUsers {
+
+		kvUserShortcuts, err := readUserShortcuts(loginUser)
+		if err != nil {
+			return err
+		}
+
+		kvShortcuts, err := kvUserShortcuts.At("shortcuts")
+		if err != nil {
+			return err
+		}
+
+		var changed bool
+
+		for _, shortcut := range kvShortcuts.Values {
+			for _, kv := range shortcut.Values {
+				if kv.Key == "StartDir" && kv.TypedValue == quotedSrcPath {
+					kv.TypedValue = quotedDstPath
+					changed = true
+				}
+			}
+		}
+
+		if changed {
+			if err = writeUserShortcuts(loginUser, kvUserShortcuts); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
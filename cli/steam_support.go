@@ -92,7 +92,7 @@ func steamFetchAppInfo(steamAppId string, kvSteamAppInfo kevlar.KeyValues, rdx r
 	return nil
 }
 
-func steamUpdateApp(steamAppId string, operatingSystem vangogh_integration.OperatingSystem, rdx redux.Readable) error {
+func steamUpdateApp(steamAppId string, operatingSystem vangogh_integration.OperatingSystem, library string, rdx redux.Readable) error {
 
 	var steamAppName string
 	if san, ok := rdx.GetLastVal(vangogh_integration.SteamTitleProperty, steamAppId); ok && san != "" {
@@ -111,7 +111,7 @@ func steamUpdateApp(steamAppId string, operatingSystem vangogh_integration.Opera
 	scaua := nod.Begin("updating and verifying %s (%s) for %s with SteamCMD, please wait...", steamAppName, steamAppId, operatingSystem)
 	defer scaua.Done()
 
-	steamAppInstallDir, err := data.AbsSteamAppInstallDir(steamAppId, operatingSystem, rdx)
+	steamAppInstallDir, err := data.AbsSteamAppInstallDir(steamAppId, operatingSystem, library, rdx)
 	if err != nil {
 		return err
 	}
@@ -130,7 +130,7 @@ func steamUpdateApp(steamAppId string, operatingSystem vangogh_integration.Opera
 	return steamcmd.AppUpdate(absSteamCmdPath, steamAppId, operatingSystem, steamAppInstallDir, steamUsername, false)
 }
 
-func steamValidateApp(steamAppId string, operatingSystem vangogh_integration.OperatingSystem, rdx redux.Readable) error {
+func steamValidateApp(steamAppId string, operatingSystem vangogh_integration.OperatingSystem, library string, rdx redux.Readable) error {
 
 	var steamAppName string
 	if san, ok := rdx.GetLastVal(vangogh_integration.SteamTitleProperty, steamAppId); ok && san != "" {
@@ -149,7 +149,7 @@ func steamValidateApp(steamAppId string, operatingSystem vangogh_integration.Ope
 	scaua := nod.Begin("updating and verifying %s (%s) for %s with SteamCMD, please wait...", steamAppName, steamAppId, operatingSystem)
 	defer scaua.Done()
 
-	steamAppInstallDir, err := data.AbsSteamAppInstallDir(steamAppId, operatingSystem, rdx)
+	steamAppInstallDir, err := data.AbsSteamAppInstallDir(steamAppId, operatingSystem, library, rdx)
 	if err != nil {
 		return err
 	}
@@ -442,13 +442,16 @@ func steamResetConnection(rdx redux.Writeable) error {
 }
 
 func steamDownloadData(steamAppId string, ii *InstallInfo, originData *data.OriginData, rdx redux.Readable) error {
-	steamAppsDir := data.Pwd.AbsDirPath(data.SteamApps)
+	steamAppsDir, err := data.AbsLibraryDir(ii.Library, data.SteamApps, rdx)
+	if err != nil {
+		return err
+	}
 
-	if err := originHasFreeSpace(steamAppId, steamAppsDir, ii, originData); err != nil {
+	if err = originHasFreeSpace(steamAppId, steamAppsDir, ii, originData); err != nil {
 		return err
 	}
 
-	return steamUpdateApp(steamAppId, ii.OperatingSystem, rdx)
+	return steamUpdateApp(steamAppId, ii.OperatingSystem, ii.Library, rdx)
 }
 
 func steamGetExecTask(steamAppId string, ii *InstallInfo, originData *data.OriginData, rdx redux.Readable, et *execTask) (*execTask, error) {
@@ -529,12 +532,12 @@ func steamDefaultTask(steamAppId string, appInfoKv steam_vdf.ValveDataFile, ii *
 		return nil, err
 	}
 
-	steamAppInstallDir, err := data.AbsSteamAppInstallDir(steamAppId, ii.OperatingSystem, rdx)
+	steamAppInstallDir, err := data.AbsSteamAppInstallDir(steamAppId, ii.OperatingSystem, ii.Library, rdx)
 	if err != nil {
 		return nil, err
 	}
 
-	absPrefixDir, err := data.AbsPrefixDir(steamAppId, ii.Origin, rdx)
+	absPrefixDir, err := data.AbsPrefixDir(steamAppId, ii.Origin, ii.Library, rdx)
 	if err != nil {
 		return nil, err
 	}
@@ -600,12 +603,12 @@ func steamNamedTask(steamAppId, task string, appInfoKv steam_vdf.ValveDataFile,
 		return nil, err
 	}
 
-	steamAppInstallDir, err := data.AbsSteamAppInstallDir(steamAppId, ii.OperatingSystem, rdx)
+	steamAppInstallDir, err := data.AbsSteamAppInstallDir(steamAppId, ii.OperatingSystem, ii.Library, rdx)
 	if err != nil {
 		return nil, err
 	}
 
-	absPrefixDir, err := data.AbsPrefixDir(steamAppId, ii.Origin, rdx)
+	absPrefixDir, err := data.AbsPrefixDir(steamAppId, ii.Origin, ii.Library, rdx)
 	if err != nil {
 		return nil, err
 	}
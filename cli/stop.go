@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"errors"
+	"net/url"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/boggydigital/nod"
+)
+
+func StopHandler(u *url.URL) error {
+
+	q := u.Query()
+
+	id := q.Get(vangogh_integration.UrlIdParameter)
+
+	ii := &InstallInfo{
+		OperatingSystem: vangogh_integration.ParseOperatingSystem(q.Get(vangogh_integration.UrlOperatingSystemParameter)),
+		LangCode:        q.Get(vangogh_integration.UrlLanguageCodeParameter),
+		force:           q.Has(vangogh_integration.UrlForceParameter),
+	}
+
+	return Stop(id, ii)
+}
+
+// Stop asks running product processes to terminate, processes that are still running
+// after the stop period are killed. With force processes are killed immediately
+func Stop(id string, request *InstallInfo) error {
+
+	sa := nod.Begin("stopping %s...", id)
+	defer sa.Done()
+
+	runStates, err := getRunStates()
+	if err != nil {
+		return err
+	}
+
+	var matching []*runState
+	for _, rs := range runStates {
+		if rs.Id != id {
+			continue
+		}
+		if request.OperatingSystem != vangogh_integration.AnyOperatingSystem && request.OperatingSystem != rs.OperatingSystem {
+			continue
+		}
+		if request.LangCode != "" && request.LangCode != rs.LangCode {
+			continue
+		}
+		matching = append(matching, rs)
+	}
+
+	switch len(matching) {
+	case 0:
+		sa.EndWithResult("not running")
+		return nil
+	case 1:
+		// do nothing
+	default:
+		return errors.New("multiple running products match request, please specify os and lang-code")
+	}
+
+	if err = stopProcessGroup(matching[0].Pgid, request.force); err != nil {
+		return err
+	}
+
+	sa.EndWithResult("stopped %s", matching[0].Title)
+
+	return nil
+}
@@ -0,0 +1,241 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json/v2"
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/pathways"
+)
+
+const (
+	runStateExt                = ".json"
+	processGroupPollInterval   = time.Second
+	processGroupStopPeriod     = 15 * time.Second
+	processGroupStopPollPeriod = 250 * time.Millisecond
+)
+
+// runState describes running product, it's stored in the run states
+// directory while the product process group is alive
+type runState struct {
+	Id              string                              `json:"id"`
+	Title           string                              `json:"title"`
+	Origin          data.Origin                         `json:"origin"`
+	OperatingSystem vangogh_integration.OperatingSystem `json:"os"`
+	LangCode        string                              `json:"lang-code"`
+	Exe             string                              `json:"exe"`
+	Pgid            int                                 `json:"pgid"`
+	TheoPid         int                                 `json:"theo-pid"`
+	TheoStart       time.Time                           `json:"theo-start"`
+	Start           time.Time                           `json:"start"`
+}
+
+func newRunState(id, title string, ii *InstallInfo) *runState {
+	return &runState{
+		Id:              id,
+		Title:           title,
+		Origin:          ii.Origin,
+		OperatingSystem: ii.OperatingSystem,
+		LangCode:        ii.LangCode,
+		TheoPid:         os.Getpid(),
+	}
+}
+
+func (rs *runState) absFilename() string {
+	ii := &InstallInfo{
+		Origin:          rs.Origin,
+		OperatingSystem: rs.OperatingSystem,
+		LangCode:        rs.LangCode,
+	}
+	return filepath.Join(data.Pwd.AbsRelDirPath(data.RunStates, data.Temp), productLockName(rs.Id, ii)+runStateExt)
+}
+
+func (rs *runState) write() error {
+
+	if err := os.MkdirAll(data.Pwd.AbsRelDirPath(data.RunStates, data.Temp), pathways.PermUrwGrwOr); err != nil {
+		return err
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := json.MarshalWrite(buf, rs); err != nil {
+		return err
+	}
+
+	return os.WriteFile(rs.absFilename(), buf.Bytes(), 0644)
+}
+
+func (rs *runState) remove() error {
+	if err := os.Remove(rs.absFilename()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// isRunning returns true if the product has a run state
+// and its process group is still alive
+func (rs *runState) isRunning() (bool, error) {
+
+	if _, err := os.Stat(rs.absFilename()); os.IsNotExist(err) {
+		return false, nil
+	}
+
+	existingRunState, err := readRunState(rs.absFilename())
+	if err != nil {
+		return false, err
+	}
+
+	if existingRunState.isStale() {
+		return false, existingRunState.remove()
+	}
+
+	return processGroupAlive(existingRunState.Pgid), nil
+}
+
+// isStale returns true if theo process that has written the run state is no
+// longer running. Run states survive theo crashes and reboots and their process
+// group ids might have been reused by unrelated processes since
+func (rs *runState) isStale() bool {
+
+	if rs.TheoStart.IsZero() {
+		return true
+	}
+
+	theoStart, err := processStartTime(rs.TheoPid)
+	if err != nil {
+		return true
+	}
+
+	return !theoStart.Equal(rs.TheoStart)
+}
+
+// runSupervised starts the command in a new process group and waits for the whole group to exit,
+// as launchers (umu-run, CrossOver, shell scripts) often exit before the game processes they've
+// started. Interrupts received by theo are forwarded to the process group. Commands without
+// run state, or on the systems where run states can't be verified, are not supervised
+func runSupervised(cmd *exec.Cmd, et *execTask) error {
+
+	if et.runState == nil {
+		return errors.Join(et.releaseRunLock(), cmd.Run())
+	}
+
+	theoStart, err := processStartTime(et.runState.TheoPid)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return errors.Join(et.releaseRunLock(), cmd.Run())
+	} else if err != nil {
+		return err
+	}
+
+	pgid, err := startProcessGroup(cmd)
+	if err != nil {
+		return err
+	}
+
+	et.runState.TheoStart = theoStart
+
+	et.runState.Exe = cmd.Path
+	et.runState.Pgid = pgid
+	et.runState.Start = time.Now().UTC()
+
+	if err = et.runState.write(); err != nil {
+		return errors.Join(err, terminateProcessGroup(pgid), cmd.Wait())
+	}
+
+	// other runs of the product are excluded by the run state from now on
+	if err = et.releaseRunLock(); err != nil {
+		return errors.Join(err, terminateProcessGroup(pgid), cmd.Wait())
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, supervisedSignals...)
+	defer signal.Stop(signals)
+
+	done := make(chan any)
+	defer close(done)
+
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				// error is not actionable here, exited process group will be detected below
+				_ = forwardSignal(pgid, sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	runErr := cmd.Wait()
+
+	for processGroupAlive(pgid) {
+		time.Sleep(processGroupPollInterval)
+	}
+
+	return errors.Join(runErr, et.runState.remove())
+}
+
+// getRunStates returns states of the running products, stale states
+// and states of the process groups that are no longer alive are removed
+func getRunStates() ([]*runState, error) {
+
+	absRunStatesDir := data.Pwd.AbsRelDirPath(data.RunStates, data.Temp)
+
+	if err := os.MkdirAll(absRunStatesDir, pathways.PermUrwGrwOr); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(absRunStatesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	runStates := make([]*runState, 0, len(entries))
+
+	for _, entry := range entries {
+
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), runStateExt) {
+			continue
+		}
+
+		absRunStateFilename := filepath.Join(absRunStatesDir, entry.Name())
+
+		rs, err := readRunState(absRunStateFilename)
+		if err != nil {
+			return nil, err
+		}
+
+		if rs.isStale() || !processGroupAlive(rs.Pgid) {
+			if err = os.Remove(absRunStateFilename); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		runStates = append(runStates, rs)
+	}
+
+	return runStates, nil
+}
+
+func readRunState(absRunStateFilename string) (*runState, error) {
+
+	runStateFile, err := os.Open(absRunStateFilename)
+	if err != nil {
+		return nil, err
+	}
+	defer runStateFile.Close()
+
+	var rs runState
+	if err = json.UnmarshalRead(runStateFile, &rs); err != nil {
+		return nil, err
+	}
+
+	return &rs, nil
+}
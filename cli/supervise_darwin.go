@@ -0,0 +1,25 @@
+//go:build darwin
+
+package cli
+
+import (
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func processStartTime(pid int) (time.Time, error) {
+
+	kinfoProc, err := unix.SysctlKinfoProc("kern.proc.pid", pid)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// sysctl returns empty process info for pids that don't exist
+	if kinfoProc.Proc.P_pid != int32(pid) {
+		return time.Time{}, os.ErrProcessDone
+	}
+
+	return time.Unix(kinfoProc.Proc.P_starttime.Unix()).UTC(), nil
+}
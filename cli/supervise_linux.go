@@ -0,0 +1,70 @@
+//go:build linux
+
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clock ticks per second used by /proc, USER_HZ is 100 on all supported architectures
+const procClockTicks = 100
+
+func processStartTime(pid int) (time.Time, error) {
+
+	statBytes, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// process name is enclosed in parentheses and might contain spaces,
+	// start time is the 22nd field and the 20th after the process name
+	stat := string(statBytes)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	if len(fields) < 20 {
+		return time.Time{}, errors.New("unexpected process stat format for pid " + strconv.Itoa(pid))
+	}
+
+	startTicks, err := strconv.ParseInt(fields[19], 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	bootTime, err := bootTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return bootTime.Add(time.Duration(startTicks) * time.Second / procClockTicks).UTC(), nil
+}
+
+func bootTime() (time.Time, error) {
+
+	statFile, err := os.Open("/proc/stat")
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer statFile.Close()
+
+	scanner := bufio.NewScanner(statFile)
+	for scanner.Scan() {
+		if btime, ok := strings.CutPrefix(scanner.Text(), "btime "); ok {
+			var seconds int64
+			if seconds, err = strconv.ParseInt(strings.TrimSpace(btime), 10, 64); err != nil {
+				return time.Time{}, err
+			}
+			return time.Unix(seconds, 0), nil
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Time{}, errors.New("boot time not found")
+}
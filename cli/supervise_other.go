@@ -0,0 +1,12 @@
+//go:build !linux && !darwin && !windows
+
+package cli
+
+import (
+	"errors"
+	"time"
+)
+
+func processStartTime(int) (time.Time, error) {
+	return time.Time{}, errors.ErrUnsupported
+}
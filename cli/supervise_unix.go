@@ -0,0 +1,70 @@
+//go:build !windows
+
+package cli
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+var supervisedSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP}
+
+func startProcessGroup(cmd *exec.Cmd) (int, error) {
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+
+	return cmd.Process.Pid, nil
+}
+
+func signalProcessGroup(pgid int, sig syscall.Signal) error {
+	if err := syscall.Kill(-pgid, sig); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	return nil
+}
+
+func forwardSignal(pgid int, sig os.Signal) error {
+	return signalProcessGroup(pgid, sig.(syscall.Signal))
+}
+
+func terminateProcessGroup(pgid int) error {
+	return signalProcessGroup(pgid, syscall.SIGTERM)
+}
+
+func processGroupAlive(pgid int) bool {
+	// signalling 0 would target theo's own process group
+	if pgid <= 0 {
+		return false
+	}
+	err := syscall.Kill(-pgid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// stopProcessGroup asks process group to terminate and kills it if
+// it's still alive after the stop period
+func stopProcessGroup(pgid int, force bool) error {
+
+	if !force {
+		if err := terminateProcessGroup(pgid); err != nil {
+			return err
+		}
+
+		deadline := time.Now().Add(processGroupStopPeriod)
+		for processGroupAlive(pgid) && time.Now().Before(deadline) {
+			time.Sleep(processGroupStopPollPeriod)
+		}
+	}
+
+	if !processGroupAlive(pgid) {
+		return nil
+	}
+
+	return signalProcessGroup(pgid, syscall.SIGKILL)
+}
@@ -0,0 +1,89 @@
+//go:build windows
+
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// Windows has no process groups that can be signalled by id: only the started
+// process is supervised and stopped, processes it has started themselves are not
+// waited for. Interrupts are delivered to the console processes by Windows itself
+var supervisedSignals = []os.Signal{os.Interrupt}
+
+// exit code reported by GetExitCodeProcess for processes that are still running
+const stillActive = 259
+
+func startProcessGroup(cmd *exec.Cmd) (int, error) {
+
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+
+	return cmd.Process.Pid, nil
+}
+
+func forwardSignal(int, os.Signal) error {
+	return nil
+}
+
+func terminateProcessGroup(pgid int) error {
+
+	process, err := windows.OpenProcess(windows.PROCESS_TERMINATE, false, uint32(pgid))
+	if err != nil {
+		// process has already exited
+		return nil
+	}
+	defer windows.CloseHandle(process)
+
+	return windows.TerminateProcess(process, 1)
+}
+
+func processGroupAlive(pgid int) bool {
+
+	if pgid <= 0 {
+		return false
+	}
+
+	process, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pgid))
+	if err != nil {
+		return false
+	}
+	defer windows.CloseHandle(process)
+
+	var exitCode uint32
+	if err = windows.GetExitCodeProcess(process, &exitCode); err != nil {
+		return false
+	}
+
+	return exitCode == stillActive
+}
+
+// stopProcessGroup terminates the process immediately, as Windows
+// doesn't provide a way to ask a GUI process to exit gracefully
+func stopProcessGroup(pgid int, _ bool) error {
+	if !processGroupAlive(pgid) {
+		return nil
+	}
+	return terminateProcessGroup(pgid)
+}
+
+func processStartTime(pid int) (time.Time, error) {
+
+	process, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer windows.CloseHandle(process)
+
+	var creationTime, exitTime, kernelTime, userTime windows.Filetime
+	if err = windows.GetProcessTimes(process, &creationTime, &exitTime, &kernelTime, &userTime); err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(0, creationTime.Nanoseconds()).UTC(), nil
+}
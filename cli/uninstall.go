@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/arelate/southern_light/egs_integration"
 	"github.com/arelate/southern_light/steamcmd"
 	"github.com/arelate/southern_light/vangogh_integration"
 	"github.com/arelate/theo/data"
@@ -45,7 +46,7 @@ func Uninstall(id string, request *InstallInfo, purge bool) error {
 	ua := nod.Begin("uninstalling %s...", id)
 	defer ua.Done()
 
-	rdx, err := redux.NewWriter(data.AbsReduxDir(), data.AllProperties()...)
+	rdx, err := newReduxWriter(data.AllProperties()...)
 	if err != nil {
 		return err
 	}
@@ -60,6 +61,12 @@ func Uninstall(id string, request *InstallInfo, purge bool) error {
 		return err
 	}
 
+	pl, err := lockProduct(id, installInfo, "uninstalling")
+	if err != nil {
+		return err
+	}
+	defer pl.release()
+
 	switch purge {
 	case true:
 		if err = originPurgeInstallation(id, installInfo, rdx); err != nil {
@@ -78,10 +85,22 @@ func Uninstall(id string, request *InstallInfo, purge bool) error {
 		return err
 	}
 
+	if profiles, ok := rdx.GetAllValues(data.LaunchOptionsProfilesProperty, data.AppOsLangCode(id, installInfo.OperatingSystem, installInfo.LangCode)); ok {
+		for _, profile := range profiles {
+			if err = LaunchOptions(id, installInfo, new(execTask{profile: profile}), true); err != nil {
+				return err
+			}
+		}
+	}
+
 	if err = unpinInstallInfo(id, installInfo, rdx); err != nil {
 		return err
 	}
 
+	if err = cutUpdateSettings(id, installInfo, rdx); err != nil {
+		return err
+	}
+
 	if err = removeSteamShortcut(id, rdx); err != nil {
 		return err
 	}
@@ -89,6 +108,31 @@ func Uninstall(id string, request *InstallInfo, purge bool) error {
 	return nil
 }
 
+// cutUpdateSettings removes update hold of the uninstalled installation,
+// and auto-update of the product when no installations remain
+func cutUpdateSettings(id string, installInfo *InstallInfo, rdx redux.Writeable) error {
+
+	if err := rdx.MustHave(data.HoldProperty, data.AutoUpdateProperty); err != nil {
+		return err
+	}
+
+	if appOsLangCode := data.AppOsLangCode(id, installInfo.OperatingSystem, installInfo.LangCode); rdx.HasKey(data.HoldProperty, appOsLangCode) {
+		if err := rdx.CutKeys(data.HoldProperty, appOsLangCode); err != nil {
+			return err
+		}
+	}
+
+	if installInfoLines, ok := rdx.GetAllValues(data.InstallInfoProperty, id); ok && len(installInfoLines) > 0 {
+		return nil
+	}
+
+	if rdx.HasKey(data.AutoUpdateProperty, id) {
+		return rdx.CutKeys(data.AutoUpdateProperty, id)
+	}
+
+	return nil
+}
+
 func originUninstall(id string, installInfo *InstallInfo, rdx redux.Writeable) error {
 
 	installedAppDir, err := originOsInstalledPath(id, installInfo, rdx)
@@ -120,10 +164,22 @@ func originUninstall(id string, installInfo *InstallInfo, rdx redux.Writeable) e
 			return err
 		}
 
+		// cached manifest might be newer than the installed version
+		var installedManifest *egs_integration.Manifest
+		if installedManifest, err = egsReadInstalledManifest(id, installInfo.OperatingSystem); err != nil {
+			return err
+		} else if installedManifest != nil {
+			originData.Manifest = installedManifest
+		}
+
 		if err = egsUninstall(id, installInfo, originData, rdx); err != nil {
 			return err
 		}
 
+		if err = egsRemoveInstalledManifest(id, installInfo.OperatingSystem); err != nil {
+			return err
+		}
+
 	default:
 		return installInfo.Origin.ErrUnsupportedOrigin()
 	}
@@ -2,8 +2,11 @@ package cli
 
 import (
 	"encoding/json/v2"
+	"errors"
 	"fmt"
+	"maps"
 	"net/url"
+	"slices"
 	"strings"
 
 	"github.com/arelate/southern_light/vangogh_integration"
@@ -22,10 +25,23 @@ func UpdateHandler(u *url.URL) error {
 	verbose := q.Has(vangogh_integration.UrlVerboseParameter)
 	force := q.Has(vangogh_integration.UrlForceParameter)
 
-	return Update(id, all, verbose, force)
+	format := q.Get(data.UrlFormatParameter)
+
+	return Update(id, all, verbose, force, format)
+}
+
+type productUpdate struct {
+	Id               string                              `json:"id"`
+	Origin           data.Origin                         `json:"origin"`
+	OperatingSystem  vangogh_integration.OperatingSystem `json:"os"`
+	LangCode         string                              `json:"lang-code"`
+	InstalledVersion string                              `json:"installed-version"`
+	LatestVersion    string                              `json:"latest-version"`
+	Held             bool                                `json:"held"`
+	Updated          bool                                `json:"updated"`
 }
 
-func Update(id string, all, verbose, force bool) error {
+func Update(id string, all, verbose, force bool, format string) error {
 
 	var updateMsg string
 	switch all {
@@ -38,39 +54,87 @@ func Update(id string, all, verbose, force bool) error {
 	ua := nod.NewProgress(updateMsg)
 	defer ua.Done()
 
-	rdx, err := redux.NewWriter(data.AbsReduxDir(), data.AllProperties()...)
+	// verbose installers output would interleave with the report
+	if format == JsonFormat && verbose {
+		return errors.New("verbose output is not supported with json format")
+	}
+
+	rdx, err := newReduxWriter(data.AllProperties()...)
 	if err != nil {
 		return err
 	}
 
-	updatedIdsInstallInfo, err := checkProductsUpdates(id, rdx, all, force)
+	updatedIdsInstallInfo, heldIdsInstallInfo, err := checkProductsUpdates(id, rdx, all, force)
 	if err != nil {
 		return err
 	}
 
-	for updatedId, installedInfoSlice := range updatedIdsInstallInfo {
-		for _, installedInfo := range installedInfoSlice {
+	productUpdates, err := updateProducts(updatedIdsInstallInfo, verbose)
+
+	if format == JsonFormat {
+		// held updates are reported, but not applied
+		for _, pu := range pendingUpdates(heldIdsInstallInfo) {
+			pu.Held = true
+			productUpdates = append(productUpdates, pu)
+		}
+		if jerr := writeJson(productUpdates); jerr != nil {
+			return jerr
+		}
+	}
+
+	return err
+}
+
+// updateProducts returns updates attempted so far, with the ones that
+// have been successfully installed marked as updated
+func updateProducts(updatedIdsInstallInfo map[string][]*InstallInfo, verbose bool) ([]*productUpdate, error) {
+
+	productUpdates := make([]*productUpdate, 0, len(updatedIdsInstallInfo))
+
+	for _, updatedId := range slices.Sorted(maps.Keys(updatedIdsInstallInfo)) {
+		for _, installedInfo := range updatedIdsInstallInfo[updatedId] {
 
-			installedInfo.verbose = verbose
-			installedInfo.force = true // forcing installation to overwrite existing installation
-			installedInfo.Version = "" // reset Version, so that new one could be set during installation
+			// product update is captured before update resets installed version
+			pu := newProductUpdate(updatedId, installedInfo)
+			productUpdates = append(productUpdates, pu)
 
-			if err = Install(updatedId, installedInfo); err != nil {
-				return err
+			if err := updateInstalledProduct(updatedId, installedInfo, verbose); err != nil {
+				return productUpdates, err
 			}
+
+			pu.Updated = true
 		}
 	}
 
-	return nil
+	return productUpdates, nil
 }
 
-func checkProductsUpdates(id string, rdx redux.Writeable, all, force bool) (map[string][]*InstallInfo, error) {
+func updateInstalledProduct(id string, installedInfo *InstallInfo, verbose bool) error {
+
+	installedInfo.verbose = verbose
+	installedInfo.force = true // forcing installation to overwrite existing installation
+	installedInfo.Version = "" // reset Version, so that new one could be set during installation
+
+	switch installedInfo.Origin {
+	case data.VangoghOrigin:
+		// full installers are downloaded, only files that changed in the new version are placed
+		return vangoghInPlaceUpdate(id, installedInfo)
+	case data.EpicGamesOrigin:
+		return egsDeltaUpdate(id, installedInfo)
+	default:
+		return Install(id, installedInfo)
+	}
+}
+
+// checkProductsUpdates returns installations that have updates available
+// and, separately, installations that have updates available, but are held
+func checkProductsUpdates(id string, rdx redux.Writeable, all, force bool) (map[string][]*InstallInfo, map[string][]*InstallInfo, error) {
 
 	cpua := nod.NewProgress("checking for products updates...")
 	defer cpua.Done()
 
 	if err := rdx.MustHave(data.InstallInfoProperty); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	checkIds := make([]string, 0)
@@ -87,12 +151,18 @@ func checkProductsUpdates(id string, rdx redux.Writeable, all, force bool) (map[
 	cpua.TotalInt(len(checkIds))
 
 	updatedIdInstalledInfo := make(map[string][]*InstallInfo)
+	heldIdInstalledInfo := make(map[string][]*InstallInfo)
 
 	for _, checkId := range checkIds {
-		if uii, err := checkProductUpdates(checkId, rdx, force); err == nil && len(uii) > 0 {
-			updatedIdInstalledInfo[checkId] = uii
-		} else if err != nil {
-			return nil, err
+		if uii, hii, err := checkProductUpdates(checkId, rdx, force); err == nil {
+			if len(uii) > 0 {
+				updatedIdInstalledInfo[checkId] = uii
+			}
+			if len(hii) > 0 {
+				heldIdInstalledInfo[checkId] = hii
+			}
+		} else {
+			return nil, nil, err
 		}
 
 		cpua.Increment()
@@ -103,22 +173,62 @@ func checkProductsUpdates(id string, rdx redux.Writeable, all, force bool) (map[
 		updatedIds = append(updatedIds, uid)
 	}
 
+	results := make([]string, 0, 2)
+
 	if len(updatedIdInstalledInfo) > 0 {
-		cpua.EndWithResult("found updates for: %s", strings.Join(updatedIds, ","))
+		results = append(results, "found updates for: "+strings.Join(updatedIds, ","))
+	}
+
+	if len(heldIdInstalledInfo) > 0 {
+		heldIds := slices.Sorted(maps.Keys(heldIdInstalledInfo))
+		results = append(results, "update available but held for: "+strings.Join(heldIds, ","))
+	}
+
+	if len(results) > 0 {
+		cpua.EndWithResult(strings.Join(results, "; "))
 	} else {
 		cpua.EndWithResult("all products are up to date")
 	}
 
-	return updatedIdInstalledInfo, nil
+	return updatedIdInstalledInfo, heldIdInstalledInfo, nil
 
 }
 
-func checkProductUpdates(id string, rdx redux.Writeable, force bool) ([]*InstallInfo, error) {
+func newProductUpdate(id string, installedInfo *InstallInfo) *productUpdate {
+	return &productUpdate{
+		Id:               id,
+		Origin:           installedInfo.Origin,
+		OperatingSystem:  installedInfo.OperatingSystem,
+		LangCode:         installedInfo.LangCode,
+		InstalledVersion: installedInfo.Version,
+		LatestVersion:    installedInfo.latestVersion,
+	}
+}
+
+func pendingUpdates(updatedIdsInstallInfo map[string][]*InstallInfo) []*productUpdate {
+
+	updatedIds := slices.Sorted(maps.Keys(updatedIdsInstallInfo))
+
+	updates := make([]*productUpdate, 0, len(updatedIds))
+
+	for _, updatedId := range updatedIds {
+		for _, installedInfo := range updatedIdsInstallInfo[updatedId] {
+			updates = append(updates, newProductUpdate(updatedId, installedInfo))
+		}
+	}
+
+	return updates
+}
+
+// checkProductUpdates returns installations that have updates available
+// and, separately, held installations that have updates available
+func checkProductUpdates(id string, rdx redux.Writeable, force bool) ([]*InstallInfo, []*InstallInfo, error) {
 
 	cpua := nod.Begin(" checking product updates for %s...", id)
 	defer cpua.Done()
 
 	updatedInstalledInfo := make([]*InstallInfo, 0)
+	heldInstalledInfo := make([]*InstallInfo, 0)
 
 	if installedInfoLines, ok := rdx.GetAllValues(data.InstallInfoProperty, id); ok {
 
@@ -126,20 +236,25 @@ func checkProductUpdates(id string, rdx redux.Writeable, force bool) ([]*Install
 
 			var installedInfo InstallInfo
 			if err := json.UnmarshalRead(strings.NewReader(line), &installedInfo); err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 
 			if updated, err := originIsInstalledInfoUpdated(id, &installedInfo, rdx, force); updated && err == nil {
+				if version, sure := heldVersion(id, &installedInfo, rdx); sure {
+					cpua.EndWithResult("update available but held at version %s", version)
+					heldInstalledInfo = append(heldInstalledInfo, &installedInfo)
+					continue
+				}
 				updatedInstalledInfo = append(updatedInstalledInfo, &installedInfo)
 			} else if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 
 		}
 
 	}
 
-	return updatedInstalledInfo, nil
+	return updatedInstalledInfo, heldInstalledInfo, nil
 
 }
 
@@ -170,6 +285,8 @@ func originIsInstalledInfoUpdated(id string, installedInfo *InstallInfo, rdx red
 		return false, installedInfo.Origin.ErrUnsupportedOrigin()
 	}
 
+	installedInfo.latestVersion = latestVersion
+
 	if installedVersion == "" && !force {
 		iiiua.EndWithResult("cannot determine installed version")
 		return false, nil
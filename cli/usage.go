@@ -0,0 +1,411 @@
+package cli
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/arelate/southern_light/steam_grid"
+	"github.com/arelate/southern_light/steam_integration"
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+const (
+	usageSortSize   = "size"
+	usageSortTitle  = "title"
+	usageSortOrigin = "origin"
+	usageSortOs     = "os"
+)
+
+// usageEstimateTolerance is a fraction of the estimated bytes that installed
+// bytes can differ by before installation is reported as differing from estimate
+const usageEstimateTolerance = 0.25
+
+func UsageSorts() []string {
+	return []string{usageSortSize, usageSortTitle, usageSortOrigin, usageSortOs}
+}
+
+type productUsage struct {
+	Id                  string                              `json:"id"`
+	Title               string                              `json:"title"`
+	OperatingSystem     vangogh_integration.OperatingSystem `json:"os"`
+	LangCode            string                              `json:"lang-code"`
+	Origin              data.Origin                         `json:"origin"`
+	Library             string                              `json:"library,omitempty"`
+	InstalledBytes      int64                               `json:"installed-bytes"`
+	DownloadsBytes      int64                               `json:"downloads-bytes"`
+	PrefixBytes         int64                               `json:"prefix-bytes"`
+	SteamGridBytes      int64                               `json:"steam-grid-bytes"`
+	EgsChunksBytes      int64                               `json:"egs-chunks-bytes"`
+	EstimatedBytes      int64                               `json:"estimated-bytes"`
+	DiffersFromEstimate bool                                `json:"differs-from-estimate"`
+}
+
+func (pu *productUsage) totalBytes() int64 {
+	return pu.InstalledBytes + pu.DownloadsBytes + pu.PrefixBytes + pu.SteamGridBytes + pu.EgsChunksBytes
+}
+
+type diskUsage struct {
+	Products []*productUsage `json:"products"`
+	Binaries []namedBytes    `json:"binaries"`
+}
+
+type namedBytes struct {
+	Name  string `json:"name"`
+	Bytes int64  `json:"bytes"`
+}
+
+func UsageHandler(u *url.URL) error {
+
+	q := u.Query()
+
+	ii := &InstallInfo{
+		OperatingSystem: vangogh_integration.AnyOperatingSystem,
+		Origin:          data.UnknownOrigin,
+	}
+
+	if q.Has(vangogh_integration.UrlOperatingSystemParameter) {
+		ii.OperatingSystem = vangogh_integration.ParseOperatingSystem(q.Get(vangogh_integration.UrlOperatingSystemParameter))
+	}
+
+	if q.Has(vangogh_integration.UrlOriginParameter) {
+		ii.Origin = data.ParseOrigin(q.Get(vangogh_integration.UrlOriginParameter))
+	}
+
+	sort := q.Get(vangogh_integration.UrlSortParameter)
+	desc := q.Has(vangogh_integration.UrlDescendingParameter)
+	format := q.Get(data.UrlFormatParameter)
+
+	return Usage(ii, sort, desc, format)
+}
+
+// Usage measures disk space actually used by installed products (installed files,
+// downloads, WINE prefixes, Steam grid images, EGS chunks) and shared binaries
+func Usage(request *InstallInfo, sort string, desc bool, format string) error {
+
+	ua := nod.Begin("measuring disk usage...")
+	defer ua.Done()
+
+	rdx, err := newReduxReader(data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	installedInfos, err := getInstalledInfos(rdx)
+	if err != nil {
+		return err
+	}
+
+	loginUsers, err := getSteamLoginUsers()
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	du := new(diskUsage)
+
+	// installations of the same product share downloads, prefix and Steam grid images
+	measured := make(map[string]any)
+
+	for _, id := range slices.Sorted(maps.Keys(installedInfos)) {
+		for _, ii := range installedInfos[id] {
+
+			if request.OperatingSystem != vangogh_integration.AnyOperatingSystem && request.OperatingSystem != ii.OperatingSystem {
+				continue
+			}
+
+			if request.Origin != data.UnknownOrigin && request.Origin != ii.Origin {
+				continue
+			}
+
+			pu, err := measureProductUsage(id, &ii, loginUsers, measured, rdx)
+			if err != nil {
+				return err
+			}
+
+			du.Products = append(du.Products, pu)
+		}
+	}
+
+	sortProductUsage(du.Products, sort, desc)
+
+	if du.Binaries, err = measureBinariesUsage(); err != nil {
+		return err
+	}
+
+	switch format {
+	case JsonFormat:
+		return writeJson(du)
+	default:
+		// do nothing
+	}
+
+	var totalBytes int64
+	summary := make(map[string][]string)
+
+	productsHeading := "installed products:"
+	differsHeading := "differs from estimate:"
+	binariesHeading := "shared binaries:"
+
+	for _, pu := range du.Products {
+
+		totalBytes += pu.totalBytes()
+
+		summary[productsHeading] = append(summary[productsHeading], formatProductUsage(pu))
+
+		if pu.DiffersFromEstimate {
+			summary[differsHeading] = append(summary[differsHeading],
+				fmt.Sprintf("%s (%s): installed %s, estimated %s",
+					pu.Title, pu.Id,
+					vangogh_integration.FormatBytes(pu.InstalledBytes),
+					vangogh_integration.FormatBytes(pu.EstimatedBytes)))
+		}
+	}
+
+	for _, nb := range du.Binaries {
+		totalBytes += nb.Bytes
+		summary[binariesHeading] = append(summary[binariesHeading],
+			fmt.Sprintf("%s: %s", nb.Name, vangogh_integration.FormatBytes(nb.Bytes)))
+	}
+
+	ua.EndWithSummary(fmt.Sprintf("total disk usage %s:", vangogh_integration.FormatBytes(totalBytes)), summary)
+
+	return nil
+}
+
+// measureProductUsage counts shared paths that haven't been measured for another installation
+func measureProductUsage(id string, ii *InstallInfo, loginUsers []string, measured map[string]any, rdx redux.Readable) (*productUsage, error) {
+
+	title, err := data.GetTitleProperty(id, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	pu := &productUsage{
+		Id:              id,
+		Title:           title,
+		OperatingSystem: ii.OperatingSystem,
+		LangCode:        ii.LangCode,
+		Origin:          ii.Origin,
+		Library:         ii.Library,
+		EstimatedBytes:  ii.EstimatedBytes,
+	}
+
+	absInstalledPath, err := originOsInstalledPath(id, ii, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	// macOS bundles are measured with the rest of the product installation directory
+	if ii.Origin == data.VangoghOrigin && ii.OperatingSystem == vangogh_integration.MacOS {
+		if bundleName, ok := rdx.GetLastVal(vangogh_integration.GogBundleNameProperty, id); ok && bundleName != "" {
+			absInstalledPath = filepath.Dir(absInstalledPath)
+		}
+	}
+
+	if pu.InstalledBytes, err = existingPathBytes(absInstalledPath); err != nil {
+		return nil, err
+	}
+
+	absPrefixDir, err := data.AbsPrefixDir(id, ii.Origin, ii.Library, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	if pu.PrefixBytes, err = sharedPathBytes(absPrefixDir, measured); err != nil {
+		return nil, err
+	}
+
+	switch ii.Origin {
+	case data.VangoghOrigin:
+		if pu.DownloadsBytes, err = sharedPathBytes(filepath.Join(data.Pwd.AbsDirPath(data.Downloads), id), measured); err != nil {
+			return nil, err
+		}
+	case data.EpicGamesOrigin:
+		for _, appName := range egsAppNames(id, rdx) {
+			var chunksBytes int64
+			if chunksBytes, err = existingPathBytes(data.AbsChunksDownloadDir(appName, ii.OperatingSystem)); err != nil {
+				return nil, err
+			}
+			pu.EgsChunksBytes += chunksBytes
+		}
+	default:
+		// do nothing
+	}
+
+	if pu.SteamGridBytes, err = steamGridImagesBytes(title, loginUsers, measured); err != nil {
+		return nil, err
+	}
+
+	if pu.EstimatedBytes > 0 {
+		diff := float64(pu.InstalledBytes-pu.EstimatedBytes) / float64(pu.EstimatedBytes)
+		pu.DiffersFromEstimate = diff > usageEstimateTolerance || diff < -usageEstimateTolerance
+	}
+
+	return pu, nil
+}
+
+// egsAppNames returns EGS main game appName followed by appNames of the DLCs
+// for that game, as DLCs are tracked in the main game install info
+func egsAppNames(mainAppName string, rdx redux.Readable) []string {
+
+	appNames := []string{mainAppName}
+
+	for appName := range rdx.Keys(vangogh_integration.EgsMainGameProperty) {
+		if requiresGame, ok := rdx.GetLastVal(vangogh_integration.EgsMainGameProperty, appName); ok && requiresGame == mainAppName {
+			appNames = append(appNames, appName)
+		}
+	}
+
+	return appNames
+}
+
+func sharedPathBytes(absPath string, measured map[string]any) (int64, error) {
+
+	if _, ok := measured[absPath]; ok {
+		return 0, nil
+	}
+
+	measured[absPath] = nil
+
+	return existingPathBytes(absPath)
+}
+
+func steamGridImagesBytes(title string, loginUsers []string, measured map[string]any) (int64, error) {
+
+	udhd, err := data.UserDataHomeDir()
+	if err != nil {
+		return 0, err
+	}
+
+	shortcutId := steam_integration.ShortcutAppId(title)
+
+	var bytes int64
+
+	for _, loginUser := range loginUsers {
+
+		absSteamGridPath := filepath.Join(udhd, "Steam", "userdata", loginUser, "config", "grid")
+
+		for _, asset := range steam_grid.ShortcutAssets {
+			var assetBytes int64
+			if assetBytes, err = sharedPathBytes(filepath.Join(absSteamGridPath, steam_grid.ImageFilename(shortcutId, asset)), measured); err != nil {
+				return 0, err
+			}
+			bytes += assetBytes
+		}
+	}
+
+	return bytes, nil
+}
+
+func measureBinariesUsage() ([]namedBytes, error) {
+
+	binaries := make([]namedBytes, 0)
+
+	wineBinaries := data.Pwd.AbsRelDirPath(data.BinUnpacks, data.Wine)
+
+	entries, err := os.ReadDir(wineBinaries)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+
+		if !entry.IsDir() {
+			continue
+		}
+
+		var bytes int64
+		if bytes, err = pathBytes(filepath.Join(wineBinaries, entry.Name())); err != nil {
+			return nil, err
+		}
+
+		binaries = append(binaries, namedBytes{Name: entry.Name(), Bytes: bytes})
+	}
+
+	for name, absDir := range map[string]string{
+		"WINE downloads":     data.Pwd.AbsRelDirPath(data.BinDownloads, data.Wine),
+		"SteamCMD":           data.Pwd.AbsRelDirPath(data.BinUnpacks, data.SteamCmd),
+		"SteamCMD downloads": data.Pwd.AbsRelDirPath(data.BinDownloads, data.SteamCmd),
+	} {
+		var bytes int64
+		if bytes, err = existingPathBytes(absDir); err != nil {
+			return nil, err
+		}
+
+		binaries = append(binaries, namedBytes{Name: name, Bytes: bytes})
+	}
+
+	slices.SortFunc(binaries, func(a, b namedBytes) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return binaries, nil
+}
+
+func sortProductUsage(products []*productUsage, sort string, desc bool) {
+
+	slices.SortFunc(products, func(a, b *productUsage) int {
+
+		var c int
+
+		switch sort {
+		case usageSortTitle:
+			// titles are compared below for every sort
+		case usageSortOrigin:
+			c = strings.Compare(a.Origin.String(), b.Origin.String())
+		case usageSortOs:
+			c = strings.Compare(a.OperatingSystem.String(), b.OperatingSystem.String())
+		default:
+			c = cmp.Compare(a.totalBytes(), b.totalBytes())
+		}
+
+		if c == 0 {
+			c = strings.Compare(a.Title, b.Title)
+		}
+
+		if desc {
+			return -c
+		}
+
+		return c
+	})
+}
+
+func formatProductUsage(pu *productUsage) string {
+
+	parts := []string{"installed " + vangogh_integration.FormatBytes(pu.InstalledBytes)}
+
+	for name, bytes := range map[string]int64{
+		"downloads":  pu.DownloadsBytes,
+		"prefix":     pu.PrefixBytes,
+		"steam grid": pu.SteamGridBytes,
+		"egs chunks": pu.EgsChunksBytes,
+	} {
+		if bytes > 0 {
+			parts = append(parts, name+" "+vangogh_integration.FormatBytes(bytes))
+		}
+	}
+
+	slices.Sort(parts[1:])
+
+	return fmt.Sprintf("%s (%s, %s, %s): %s - %s",
+		pu.Title, pu.Id, pu.Origin, data.OsLangCode(pu.OperatingSystem, pu.LangCode),
+		vangogh_integration.FormatBytes(pu.totalBytes()),
+		strings.Join(parts, ", "))
+}
+
+// existingPathBytes returns size of a path, paths that don't exist use no space
+func existingPathBytes(absPath string) (int64, error) {
+	if _, err := os.Stat(absPath); os.IsNotExist(err) {
+		return 0, nil
+	}
+	return pathBytes(absPath)
+}
@@ -1,15 +1,16 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
+	"os"
 	"slices"
 	"strings"
 
 	"github.com/arelate/southern_light/vangogh_integration"
 	"github.com/arelate/theo/data"
 	"github.com/boggydigital/nod"
-	"github.com/boggydigital/redux"
 )
 
 type ValidationResult string
@@ -44,9 +45,9 @@ func ValidateHandler(u *url.URL) error {
 
 	id := q.Get(vangogh_integration.UrlIdParameter)
 
-	os := vangogh_integration.AnyOperatingSystem
+	operatingSystem := vangogh_integration.AnyOperatingSystem
 	if q.Has(vangogh_integration.UrlOperatingSystemParameter) {
-		os = vangogh_integration.ParseOperatingSystem(q.Get(vangogh_integration.UrlOperatingSystemParameter))
+		operatingSystem = vangogh_integration.ParseOperatingSystem(q.Get(vangogh_integration.UrlOperatingSystemParameter))
 	}
 
 	var langCode string
@@ -56,7 +57,7 @@ func ValidateHandler(u *url.URL) error {
 
 	ii := &InstallInfo{
 		Origin:          data.VangoghOrigin,
-		OperatingSystem: os,
+		OperatingSystem: operatingSystem,
 		LangCode:        langCode,
 		force:           q.Has(vangogh_integration.UrlForceParameter),
 	}
@@ -74,35 +75,139 @@ func ValidateHandler(u *url.URL) error {
 		manualUrlFilter = strings.Split(q.Get(vangogh_integration.UrlManualUrlFilterParameter), ",")
 	}
 
+	installed, err := validatesInstalled(id, ii)
+	if err != nil {
+		return err
+	}
+
+	repair := q.Has(data.UrlRepairParameter)
+
+	if q.Get(data.UrlFormatParameter) == JsonFormat {
+		return validateJson(id, ii, installed, repair, manualUrlFilter...)
+	}
+
+	if installed {
+		_, err = validateInstalled(id, ii, repair)
+		return err
+	}
+
 	return Validate(id, ii, manualUrlFilter...)
 }
 
+type fileValidation struct {
+	Filename  string           `json:"filename"`
+	ManualUrl string           `json:"manual-url,omitempty"`
+	Result    ValidationResult `json:"result"`
+	Error     string           `json:"error,omitempty"`
+}
+
 func Validate(id string,
 	ii *InstallInfo,
 	manualUrlFilter ...string) error {
 
+	_, err := validateFiles(id, ii, manualUrlFilter...)
+	return err
+}
+
+func validateJson(id string, ii *InstallInfo, installed, repair bool, manualUrlFilter ...string) error {
+
+	if ii.Origin == data.SteamOrigin {
+		return errors.New("json format is not supported for Steam validation")
+	}
+
+	var fileValidations []*fileValidation
+	var err error
+
+	switch installed {
+	case true:
+		fileValidations, err = validateInstalled(id, ii, repair)
+	case false:
+		fileValidations, err = validateFiles(id, ii, manualUrlFilter...)
+	}
+
+	if fileValidations != nil {
+		if jerr := writeJson(fileValidations); jerr != nil {
+			return jerr
+		}
+	}
+
+	return err
+}
+
+func validateFiles(id string,
+	ii *InstallInfo,
+	manualUrlFilter ...string) ([]*fileValidation, error) {
+
 	va := nod.Begin("validating %s: %s...", ii.Origin, id)
 	defer va.Done()
 
-	rdx, err := redux.NewWriter(data.AbsReduxDir(), data.AllProperties()...)
+	rdx, err := newReduxWriter(data.AllProperties()...)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	originData, err := originGetData(id, ii, rdx, false)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	switch ii.Origin {
 	case data.VangoghOrigin:
 		return vangoghValidateData(id, ii, originData, rdx, manualUrlFilter...)
 	case data.SteamOrigin:
-		return steamUpdateApp(id, ii.OperatingSystem, rdx)
+		return nil, steamUpdateApp(id, ii.OperatingSystem, ii.Library, rdx)
 	case data.EpicGamesOrigin:
 		return egsValidateChunks(id, ii, originData)
 	default:
-		return ii.Origin.ErrUnsupportedOrigin()
+		return nil, ii.Origin.ErrUnsupportedOrigin()
+	}
+}
+
+// validatesInstalled returns true for EGS products that are installed or don't
+// have chunks downloaded, as their installed files are validated instead
+func validatesInstalled(id string, ii *InstallInfo) (bool, error) {
+
+	if ii.Origin != data.EpicGamesOrigin {
+		return false, nil
+	}
+
+	rdx, err := newReduxReader(data.AllProperties()...)
+	if err != nil {
+		return false, err
+	}
+
+	if installed, err := hasInstallInfo(id, ii, rdx); err != nil || installed {
+		return installed, err
+	}
+
+	_, err = os.Stat(data.AbsChunksDownloadDir(id, ii.OperatingSystem))
+	return os.IsNotExist(err), nil
+}
+
+// validateInstalled validates installed files instead of downloads, which is
+// supported for EGS products that don't keep chunks after installation
+func validateInstalled(id string, request *InstallInfo, repair bool) ([]*fileValidation, error) {
+
+	via := nod.Begin("validating installed %s: %s...", request.Origin, id)
+	defer via.Done()
+
+	rdx, err := newReduxWriter(data.AllProperties()...)
+	if err != nil {
+		return nil, err
+	}
+
+	ii, err := matchInstalledInfo(id, request, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	ii.force = request.force
+
+	switch ii.Origin {
+	case data.EpicGamesOrigin:
+		return egsValidateInstalled(id, ii, repair, rdx)
+	default:
+		return nil, ii.Origin.ErrUnsupportedOrigin()
 	}
 }
 
@@ -0,0 +1,433 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+const compareBufferSize = 1024 * 1024
+
+// vangoghInPlaceUpdate updates installed product in place. This is not a delta update:
+// GOG patches can only be applied by the Windows installers, so full installers are
+// downloaded and unpacked for every update. Only placement is incremental: unpacked files
+// are compared to the installed files, only changed and added files are placed and files
+// that are not present in the new version are removed. Products installed without
+// inventory are reinstalled
+func vangoghInPlaceUpdate(id string, ii *InstallInfo) error {
+
+	uipa := nod.Begin("updating %s in place (downloading full installers, placing changed files)...", id)
+	defer uipa.Done()
+
+	rdx, err := newReduxWriter(data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	absInstalledPath, err := originOsInstalledPath(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	relInventory, err := readInventory(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	if _, err = os.Stat(absInstalledPath); os.IsNotExist(err) || len(relInventory) == 0 {
+		uipa.EndWithResult("inventory is not available, reinstalling")
+		return Install(id, ii)
+	}
+
+	if err = ii.backupMetadata(); err != nil {
+		return err
+	}
+
+	originData, err := originGetData(id, ii, rdx, true)
+	if err != nil {
+		return err
+	}
+
+	pl, err := lockProduct(id, ii, "updating")
+	if err != nil {
+		return err
+	}
+	defer pl.release()
+
+	it, err := beginInstallTransaction(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	if err = vangoghInPlaceUpdateSteps(id, ii, originData, relInventory, rdx, it); err != nil {
+		return it.rollback(err)
+	}
+
+	if err = it.commit(); err != nil {
+		return err
+	}
+
+	if !ii.KeepDownloads {
+		if err = RemoveDownloads(id, ii, rdx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func vangoghInPlaceUpdateSteps(id string, ii *InstallInfo, originData *data.OriginData, relInventory []string, rdx redux.Writeable, it *installTransaction) error {
+
+	if err := Download(id, ii, originData); err != nil {
+		return err
+	}
+
+	if err := Validate(id, ii); err != nil {
+		return err
+	}
+
+	if err := osPreInstallActions(id, ii, rdx); err != nil {
+		return err
+	}
+
+	if err := it.do(stepPlaceChangedFiles,
+		func() error { return vangoghPlaceChangedFiles(id, ii, originData, relInventory, rdx, it.absStagingDir) }); err != nil {
+		return err
+	}
+
+	if err := it.do(stepPinInstallInfo,
+		func() error { return pinInstallInfo(id, ii, rdx) }); err != nil {
+		return err
+	}
+
+	idInstalledDate := map[string][]string{id: {time.Now().UTC().Format(time.RFC3339)}}
+	return it.do(stepSetInstallDate,
+		func() error { return rdx.BatchReplaceValues(data.InstallDateProperty, idInstalledDate) })
+}
+
+func vangoghPlaceChangedFiles(id string, ii *InstallInfo, originData *data.OriginData, relInventory []string, rdx redux.Writeable, absStagingDir string) error {
+
+	pcfa := nod.Begin("placing changed files for %s %s-%s...", id, ii.OperatingSystem, ii.LangCode)
+	defer pcfa.Done()
+
+	dls := vangoghInstallLinks(ii, originData.ProductDetails)
+
+	if len(dls) == 0 {
+		return errors.New("no links are matching install params")
+	}
+
+	dlcNames := make(map[string]any)
+	for _, dl := range dls {
+		if dl.DownloadType == vangogh_integration.DLC {
+			dlcNames[dl.Name] = nil
+		}
+	}
+
+	if len(dlcNames) > 0 {
+		ii.DownloadableContent = slices.Collect(maps.Keys(dlcNames))
+	}
+
+	// vangogh in-place update:
+	// 1. check available space
+	// 2. unpack installers and perform post-unpack actions
+	// 3. compare unpacked files to the installed files
+	// 4. stage installed files that will be replaced or removed
+	// 5. place changed and added files, remove files that are gone
+	// 6. write inventory of the new version
+	// 7. perform post-install actions, checksum placed files
+	// 8. cleanup unpack directory
+
+	// 1
+	installedAppsDir, err := originLibraryDir(ii, data.InstalledApps, rdx)
+	if err != nil {
+		return err
+	}
+
+	if err = originHasFreeSpace(id, installedAppsDir, ii, originData); err != nil {
+		return err
+	}
+
+	// 2
+	unpackDir, err := vangoghGetUnpackDir(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	if err = vangoghUnpackInstallers(id, ii, dls, rdx, unpackDir); err != nil {
+		return err
+	}
+
+	if err = vangoghPostUnpackActions(id, ii, dls, unpackDir, rdx); err != nil {
+		return err
+	}
+
+	absInstalledPath, err := originOsInstalledPath(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	if _, err = os.Stat(absInstalledPath); os.IsNotExist(err) {
+		return errors.New("installed product not found at " + absInstalledPath + ", reinstall with force")
+	}
+
+	// 3
+	unpackedFiles, err := vangoghUnpackedFiles(ii, dls, unpackDir)
+	if err != nil {
+		return err
+	}
+
+	delta, err := diffInstalledFiles(absInstalledPath, unpackedFiles, relInventory)
+	if err != nil {
+		return err
+	}
+
+	// 4
+	previousEntries, err := readInventoryEntries(id, ii.LangCode, ii.OperatingSystem, rdx)
+	if err != nil {
+		return err
+	}
+
+	absInventoryFilename, err := data.AbsInventoryFilename(id, ii.LangCode, ii.OperatingSystem, rdx)
+	if err != nil {
+		return err
+	}
+
+	if err = stageDeltaFiles(absStagingDir, absInstalledPath, absInventoryFilename, delta); err != nil {
+		return err
+	}
+
+	// 5
+	for _, relFile := range slices.Concat(delta[deltaChanged], delta[deltaAdded]) {
+		if err = placeFile(unpackedFiles[relFile], filepath.Join(absInstalledPath, relFile)); err != nil {
+			return err
+		}
+	}
+
+	for _, relFile := range delta[deltaRemoved] {
+		if err = removeEmptyDirs(absInstalledPath, filepath.Dir(relFile)); err != nil {
+			return err
+		}
+	}
+
+	// 6
+	// unchanged files keep their checksums, placed files are checksummed after post-install actions
+	previousChecksums := make(map[string]inventoryEntry, len(previousEntries))
+	for _, pe := range previousEntries {
+		previousChecksums[pe.Path] = pe
+	}
+
+	unchangedFiles := make(map[string]any, len(delta[deltaUnchanged]))
+	for _, relFile := range delta[deltaUnchanged] {
+		unchangedFiles[relFile] = nil
+	}
+
+	entries := make([]inventoryEntry, 0, len(unpackedFiles))
+	for _, relFile := range slices.Sorted(maps.Keys(unpackedFiles)) {
+		entry := inventoryEntry{Path: relFile}
+		if _, ok := unchangedFiles[relFile]; ok {
+			if pe, sure := previousChecksums[relFile]; sure {
+				entry = pe
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = writeInventory(id, ii.LangCode, ii.OperatingSystem, rdx, entries...); err != nil {
+		return err
+	}
+
+	// 7
+	if err = vangoghPostInstallActions(id, ii, dls, rdx, unpackDir); err != nil {
+		return err
+	}
+
+	if err = checksumInventory(id, ii, rdx); err != nil {
+		return err
+	}
+
+	// 8
+	if err = os.RemoveAll(unpackDir); err != nil {
+		return err
+	}
+
+	summary := make(map[string][]string)
+	for _, dt := range []string{deltaChanged, deltaAdded, deltaRemoved, deltaUnchanged} {
+		summary[dt] = []string{strconv.Itoa(len(delta[dt])) + " file(s)"}
+	}
+
+	pcfa.EndWithSummary("in-place update results:", summary)
+
+	return nil
+}
+
+// vangoghInstallLinks returns installer links followed by DLC links, matching
+// the order in which they are placed during installation
+func vangoghInstallLinks(ii *InstallInfo, productDetails *vangogh_integration.ProductDetails) vangogh_integration.ProductDownloadLinks {
+
+	dls := productDetails.DownloadLinks.
+		FilterOperatingSystems(ii.OperatingSystem).
+		FilterLanguageCodes(ii.LangCode)
+
+	installLinks := dls.FilterDownloadTypes(vangogh_integration.Installer)
+
+	switch ii.NoDlcs {
+	case false:
+		installLinks = append(installLinks, dls.FilterDownloadTypes(vangogh_integration.DLC)...)
+	default:
+		// do nothing
+	}
+
+	return installLinks
+}
+
+// vangoghUnpackedFiles maps files relative to the installed path to their unpacked
+// location, following the same rules that are used to place unpacked files
+func vangoghUnpackedFiles(ii *InstallInfo, dls vangogh_integration.ProductDownloadLinks, unpackDir string) (map[string]string, error) {
+
+	unpackedFiles := make(map[string]string)
+
+	installerUnpacked := false
+
+	for _, link := range dls {
+
+		if !isLinkExecutable(&link, ii.OperatingSystem) {
+			continue
+		}
+
+		var absUnpackedPath string
+
+		switch ii.OperatingSystem {
+		case vangogh_integration.MacOS:
+			// see the comment in macOsUnpackInstallers
+			if link.DownloadType == vangogh_integration.Installer && installerUnpacked && !ii.force {
+				continue
+			}
+			absUnpackedPath = filepath.Join(unpackDir, link.LocalFilename, relPayloadPath)
+		case vangogh_integration.Linux:
+			absUnpackedPath = filepath.Join(unpackDir, link.LocalFilename, relExtractedDataPath)
+		case vangogh_integration.Windows:
+			absUnpackedPath = filepath.Join(unpackDir, link.LocalFilename)
+		default:
+			return nil, ii.OperatingSystem.ErrUnsupported()
+		}
+
+		if _, err := os.Stat(absUnpackedPath); os.IsNotExist(err) {
+			return nil, ErrMissingExtractedPayload
+		}
+
+		relFiles, err := relWalkDir(absUnpackedPath)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, relFile := range relFiles {
+			unpackedFiles[relFile] = filepath.Join(absUnpackedPath, relFile)
+		}
+
+		if link.DownloadType == vangogh_integration.Installer {
+			installerUnpacked = true
+		}
+	}
+
+	return unpackedFiles, nil
+}
+
+func diffInstalledFiles(absInstalledPath string, unpackedFiles map[string]string, relInventory []string) (map[string][]string, error) {
+
+	difa := nod.NewProgress(" comparing installed files...")
+	defer difa.Done()
+
+	difa.TotalInt(len(unpackedFiles))
+
+	delta := make(map[string][]string)
+
+	for _, relFile := range slices.Sorted(maps.Keys(unpackedFiles)) {
+
+		absInstalledFile := filepath.Join(absInstalledPath, relFile)
+
+		if _, err := os.Stat(absInstalledFile); os.IsNotExist(err) {
+			delta[deltaAdded] = append(delta[deltaAdded], relFile)
+		} else if same, err := sameFileContent(unpackedFiles[relFile], absInstalledFile); err != nil {
+			return nil, err
+		} else if same {
+			delta[deltaUnchanged] = append(delta[deltaUnchanged], relFile)
+		} else {
+			delta[deltaChanged] = append(delta[deltaChanged], relFile)
+		}
+
+		difa.Increment()
+	}
+
+	for _, relFile := range relInventory {
+		if _, ok := unpackedFiles[relFile]; ok {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(absInstalledPath, relFile)); err == nil {
+			delta[deltaRemoved] = append(delta[deltaRemoved], relFile)
+		}
+	}
+
+	return delta, nil
+}
+
+func sameFileContent(absPath, anotherAbsPath string) (bool, error) {
+
+	stat, err := os.Stat(absPath)
+	if err != nil {
+		return false, err
+	}
+
+	anotherStat, err := os.Stat(anotherAbsPath)
+	if err != nil {
+		return false, err
+	}
+
+	if stat.Size() != anotherStat.Size() {
+		return false, nil
+	}
+
+	file, err := os.Open(absPath)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	anotherFile, err := os.Open(anotherAbsPath)
+	if err != nil {
+		return false, err
+	}
+	defer anotherFile.Close()
+
+	buf := make([]byte, compareBufferSize)
+	anotherBuf := make([]byte, compareBufferSize)
+
+	for {
+		n, err := io.ReadFull(file, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return false, err
+		}
+
+		if _, err = io.ReadFull(anotherFile, anotherBuf[:n]); err != nil && err != io.EOF {
+			return false, err
+		}
+
+		if !bytes.Equal(buf[:n], anotherBuf[:n]) {
+			return false, nil
+		}
+
+		if n < compareBufferSize {
+			return true, nil
+		}
+	}
+}
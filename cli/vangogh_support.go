@@ -238,7 +238,7 @@ func vangoghFetchAvailableProducts(kvAvailableProducts kevlar.KeyValues) error {
 	vgapa := nod.Begin(" fetching vangogh available products...")
 	defer vgapa.Done()
 
-	rdx, err := redux.NewWriter(data.AbsReduxDir(), data.VangoghProperties()...)
+	rdx, err := newReduxWriter(data.VangoghProperties()...)
 	if err != nil {
 		return err
 	}
@@ -528,13 +528,16 @@ func vangoghUnpackPlace(id string, ii *InstallInfo, dt vangogh_integration.Downl
 	// 4. uninstall if installed directory exists and forcing install (will be used for updates)
 	// 5. create inventory of unpacked files
 	// 6. place (move unpacked to install folder)
-	// 7. perform post-install actions (e.g. run post-install script and remove xattrs on macOS)
+	// 7. perform post-install actions (e.g. run post-install script and remove xattrs on macOS), checksum placed files
 	// 8. cleanup unpack directory
 
 	// 1
-	installedAppsDir := data.Pwd.AbsDirPath(data.InstalledApps)
+	installedAppsDir, err := originLibraryDir(ii, data.InstalledApps, rdx)
+	if err != nil {
+		return err
+	}
 
-	if err := originHasFreeSpace(id, installedAppsDir, ii, originData); err != nil {
+	if err = originHasFreeSpace(id, installedAppsDir, ii, originData); err != nil {
 		return err
 	}
 
@@ -585,6 +588,10 @@ func vangoghUnpackPlace(id string, ii *InstallInfo, dt vangogh_integration.Downl
 		return err
 	}
 
+	if err = checksumInventory(id, ii, rdx); err != nil {
+		return err
+	}
+
 	// 8
 	if err = os.RemoveAll(unpackDir); err != nil {
 		return err
@@ -595,7 +602,13 @@ func vangoghUnpackPlace(id string, ii *InstallInfo, dt vangogh_integration.Downl
 
 func vangoghGetUnpackDir(id string, ii *InstallInfo, rdx redux.Readable) (string, error) {
 
-	unpackDir := filepath.Join(data.Pwd.AbsDirPath(data.Temp), id)
+	// unpacking in the library keeps placing unpacked files on the same volume
+	libraryTempDir, err := data.AbsLibraryDir(ii.Library, data.Temp, rdx)
+	if err != nil {
+		return "", err
+	}
+
+	unpackDir := filepath.Join(libraryTempDir, id)
 
 	switch ii.OperatingSystem {
 	case vangogh_integration.Windows:
@@ -607,11 +620,11 @@ func vangoghGetUnpackDir(id string, ii *InstallInfo, rdx redux.Readable) (string
 			case true:
 				// do nothing
 			case false:
-				return prefixTempUnpackDir(id, ii.Origin, rdx)
+				return prefixTempUnpackDir(id, ii, rdx)
 			}
 
 		case vangogh_integration.Linux:
-			return prefixTempUnpackDir(id, ii.Origin, rdx)
+			return prefixTempUnpackDir(id, ii, rdx)
 		default:
 			// do nothing
 		}
@@ -727,7 +740,7 @@ func vangoghPlaceUnpackedLinkPayload(link *vangogh_integration.ProductDownloadLi
 			}
 		}
 
-		if err = os.Rename(absSrcPath, absDstPath); err != nil {
+		if err = moveAll(absSrcPath, absDstPath); err != nil {
 			return err
 		}
 	}
@@ -736,9 +749,21 @@ func vangoghPlaceUnpackedLinkPayload(link *vangogh_integration.ProductDownloadLi
 }
 
 func vangoghPostInstallActions(id string, ii *InstallInfo, dls vangogh_integration.ProductDownloadLinks, rdx redux.Readable, unpackDir string) error {
+
+	absInstalledPath, err := originOsInstalledPath(id, ii, rdx)
+	if err != nil {
+		return err
+	}
+
+	return vangoghPostInstallActionsAt(id, ii, dls, unpackDir, absInstalledPath)
+}
+
+// vangoghPostInstallActionsAt runs post-install actions for the installation at a given
+// path, e.g. to repair files without running post-install actions for the whole installation
+func vangoghPostInstallActionsAt(id string, ii *InstallInfo, dls vangogh_integration.ProductDownloadLinks, unpackDir, absInstalledPath string) error {
 	switch ii.OperatingSystem {
 	case vangogh_integration.MacOS:
-		return macOsPostInstallActions(id, ii, dls, rdx, unpackDir, ii.force)
+		return macOsPostInstallActions(id, dls, unpackDir, absInstalledPath, ii.force)
 	default:
 		return nil
 	}
@@ -776,6 +801,8 @@ func vangoghDownloadData(id string, ii *InstallInfo, originData *data.OriginData
 		return errors.New("no links are matching operating params")
 	}
 
+	pendingDls := make([]vangogh_integration.ProductDownloadLink, 0, len(dls))
+
 	for _, dl := range dls {
 
 		if dl.LocalFilename == "" {
@@ -793,31 +820,110 @@ func vangoghDownloadData(id string, ii *InstallInfo, originData *data.OriginData
 			return errors.New(errMsg)
 		}
 
-		fa := nod.NewProgress(" - %s...", dl.LocalFilename)
+		pendingDls = append(pendingDls, dl)
+	}
 
-		query := url.Values{
-			vangogh_integration.UrlManualUrlParameter:    {dl.ManualUrl},
-			vangogh_integration.UrlIdParameter:           {id},
-			vangogh_integration.UrlDownloadTypeParameter: {dl.DownloadType.String()},
-		}
+	concurrency := ii.concurrency
+	if concurrency < 1 {
+		concurrency = defaultDownloadConcurrency
+	}
 
-		fileUrl, err := data.VangoghUrl(data.ApiFilePath, query, rdx)
-		if err != nil {
-			fa.EndWithResult(err.Error())
-			continue
-		}
+	dlsCh := make(chan vangogh_integration.ProductDownloadLink)
+	resultsCh := make(chan *vangoghDownloadResult)
 
-		if err = dc.Download(fileUrl, ii.force, fa, downloadsDir, id, dl.LocalFilename); err != nil {
-			fa.EndWithResult(err.Error())
-			continue
+	for range min(concurrency, max(len(pendingDls), 1)) {
+		go func() {
+			for dl := range dlsCh {
+				resultsCh <- vangoghDownloadLink(id, &dl, dc, downloadsDir, rdx, ii.force)
+			}
+		}()
+	}
+
+	go func() {
+		for _, dl := range pendingDls {
+			dlsCh <- dl
 		}
+		close(dlsCh)
+	}()
+
+	summary := make(map[string][]string)
+
+	for range pendingDls {
+		result := <-resultsCh
+		switch {
+		case result.err != nil:
+			summary[vangoghDownloadFailed] = append(summary[vangoghDownloadFailed], result.localFilename+": "+result.err.Error())
+		case result.skipped:
+			summary[vangoghDownloadSkipped] = append(summary[vangoghDownloadSkipped], result.localFilename)
+		default:
+			summary[vangoghDownloadSucceeded] = append(summary[vangoghDownloadSucceeded], result.localFilename)
+		}
+	}
 
-		fa.Done()
+	for _, results := range summary {
+		slices.Sort(results)
+	}
+
+	sda := nod.Begin("summarizing downloads...")
+	sda.EndWithSummary("download results:", summary)
+
+	if failed := len(summary[vangoghDownloadFailed]); failed > 0 {
+		return fmt.Errorf("failed to download %d of %d file(s)", failed, len(pendingDls))
 	}
 
 	return nil
 }
 
+const (
+	vangoghDownloadSucceeded = "succeeded"
+	vangoghDownloadSkipped   = "skipped"
+	vangoghDownloadFailed    = "failed"
+)
+
+type vangoghDownloadResult struct {
+	localFilename string
+	skipped       bool
+	err           error
+}
+
+func vangoghDownloadLink(id string,
+	dl *vangogh_integration.ProductDownloadLink,
+	dc *dolo.Client,
+	downloadsDir string,
+	rdx redux.Readable,
+	force bool) *vangoghDownloadResult {
+
+	result := &vangoghDownloadResult{localFilename: dl.LocalFilename}
+
+	if !force {
+		if stat, err := os.Stat(filepath.Join(downloadsDir, id, dl.LocalFilename)); err == nil && stat.Size() > 0 {
+			result.skipped = true
+			return result
+		}
+	}
+
+	fa := nod.NewProgress(" - %s...", dl.LocalFilename)
+	defer fa.Done()
+
+	query := url.Values{
+		vangogh_integration.UrlManualUrlParameter:    {dl.ManualUrl},
+		vangogh_integration.UrlIdParameter:           {id},
+		vangogh_integration.UrlDownloadTypeParameter: {dl.DownloadType.String()},
+	}
+
+	fileUrl, err := data.VangoghUrl(data.ApiFilePath, query, rdx)
+	if err != nil {
+		result.err = err
+		return result
+	}
+
+	if err = dc.Download(fileUrl, force, fa, downloadsDir, id, dl.LocalFilename); err != nil {
+		result.err = err
+	}
+
+	return result
+}
+
 func vangoghRemoveProductDownloadLinks(id string,
 	productDetails *vangogh_integration.ProductDetails,
 	ii *InstallInfo,
@@ -894,7 +1000,7 @@ func vangoghGetExecTask(id string, ii *InstallInfo, rdx redux.Readable, et *exec
 	if ii.OperatingSystem == vangogh_integration.Windows && data.CurrentOs() != vangogh_integration.Windows {
 
 		var absPrefixDir string
-		if absPrefixDir, err = data.AbsPrefixDir(id, ii.Origin, rdx); err == nil {
+		if absPrefixDir, err = data.AbsPrefixDir(id, ii.Origin, ii.Library, rdx); err == nil {
 			et.prefix = absPrefixDir
 		} else {
 			return nil, err
@@ -934,45 +1040,54 @@ func vangoghGetExecTask(id string, ii *InstallInfo, rdx redux.Readable, et *exec
 	return et, nil
 }
 
-func vangoghValidateData(id string, ii *InstallInfo, originData *data.OriginData, rdx redux.Writeable, manualUrlFilter ...string) error {
+func vangoghValidateData(id string, ii *InstallInfo, originData *data.OriginData, rdx redux.Writeable, manualUrlFilter ...string) ([]*fileValidation, error) {
 	va := nod.NewProgress("validating downloads...")
 	defer va.Done()
 
 	// always request new manual-url-checksums to avoid potentially reusing existing stale data
 	manualUrlChecksums, err := getManualUrlChecksums(id, rdx, true)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// TODO: currently this never returns an error, consider replacing redownload loop with an error
 	// and a parameter `no-validation`
 
+	fileValidations, err := vangoghValidateLinks(id, ii, manualUrlFilter, originData.ProductDetails, manualUrlChecksums)
+	if err != nil {
+		return nil, err
+	}
+
 	var mismatchedManualUrls []string
-	if mismatchedManualUrls, err = vangoghValidateLinks(id, ii, manualUrlFilter, originData.ProductDetails, manualUrlChecksums); err != nil {
-		return err
-	} else if len(mismatchedManualUrls) > 0 {
+	for _, fv := range fileValidations {
+		if fv.Result == ValResMismatch {
+			mismatchedManualUrls = append(mismatchedManualUrls, fv.ManualUrl)
+		}
+	}
+
+	if len(mismatchedManualUrls) > 0 {
 
 		// redownload and revalidate any manual-urls that resulted in mismatched checksums
 
 		ii.force = true
 
 		if err = Download(id, ii, nil, mismatchedManualUrls...); err != nil {
-			return err
+			return nil, err
 		}
 
-		if _, err = vangoghValidateLinks(id, ii, manualUrlFilter, originData.ProductDetails, manualUrlChecksums); err != nil {
-			return err
+		if fileValidations, err = vangoghValidateLinks(id, ii, manualUrlFilter, originData.ProductDetails, manualUrlChecksums); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil
+	return fileValidations, nil
 }
 
 func vangoghValidateLinks(id string,
 	ii *InstallInfo,
 	manualUrlFilter []string,
 	productDetails *vangogh_integration.ProductDetails,
-	manualUrlChecksums map[string]string) ([]string, error) {
+	manualUrlChecksums map[string]string) ([]*fileValidation, error) {
 
 	vla := nod.NewProgress("validating %s...", productDetails.Title)
 	defer vla.Done()
@@ -996,29 +1111,33 @@ func vangoghValidateLinks(id string,
 	vla.TotalInt(len(dls))
 
 	results := make([]ValidationResult, 0, len(dls))
-
-	var mismatchedManualUrls []string
+	fileValidations := make([]*fileValidation, 0, len(dls))
 
 	for _, dl := range dls {
 		if len(manualUrlFilter) > 0 && !slices.Contains(manualUrlFilter, dl.ManualUrl) {
 			continue
 		}
 
+		fv := &fileValidation{
+			Filename:  dl.LocalFilename,
+			ManualUrl: dl.ManualUrl,
+		}
+
 		vr, err := vangoghValidateLink(id, &dl, manualUrlChecksums[dl.ManualUrl], downloadsDir)
 		if err != nil {
 			vla.Error(err)
+			fv.Error = err.Error()
 		}
 
-		if vr == ValResMismatch {
-			mismatchedManualUrls = append(mismatchedManualUrls, dl.ManualUrl)
-		}
+		fv.Result = vr
 
 		results = append(results, vr)
+		fileValidations = append(fileValidations, fv)
 	}
 
 	vla.EndWithResult(summarizeValidationResults(results))
 
-	return mismatchedManualUrls, nil
+	return fileValidations, nil
 }
 
 func vangoghValidateLink(id string, link *vangogh_integration.ProductDownloadLink, manualUrlMd5 string, downloadsDir string) (ValidationResult, error) {
@@ -0,0 +1,354 @@
+package cli
+
+import (
+	"errors"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+const (
+	verifyValid      = "valid"
+	verifyMissing    = "missing"
+	verifyModified   = "modified"
+	verifyExtra      = "extra"
+	verifyUnverified = "unverified"
+	verifyRepaired   = "repaired"
+	verifyUnrepaired = "not repaired"
+)
+
+// repairDirname is the unpacked installation copy used to repair damaged files
+const repairDirname = "_repair"
+
+func VerifyHandler(u *url.URL) error {
+
+	q := u.Query()
+
+	id := q.Get(vangogh_integration.UrlIdParameter)
+
+	ii := &InstallInfo{
+		OperatingSystem: vangogh_integration.ParseOperatingSystem(q.Get(vangogh_integration.UrlOperatingSystemParameter)),
+		LangCode:        q.Get(vangogh_integration.UrlLanguageCodeParameter),
+	}
+
+	repair := q.Has(data.UrlRepairParameter)
+
+	return Verify(id, ii, repair)
+}
+
+// Verify compares installed files to the sizes and checksums recorded when
+// they were placed and reports missing, modified and extra files. Repair
+// replaces missing and modified files with the files from the installers
+func Verify(id string, request *InstallInfo, repair bool) error {
+
+	va := nod.Begin("verifying %s...", id)
+	defer va.Done()
+
+	rdx, err := newReduxWriter(data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	ii, err := matchInstalledInfo(id, request, rdx)
+	if err != nil {
+		return err
+	}
+
+	var results map[string][]string
+
+	switch ii.Origin {
+	case data.VangoghOrigin:
+		results, err = vangoghVerify(id, ii, repair, rdx)
+	default:
+		err = ii.Origin.ErrUnsupportedOrigin()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	summary := make(map[string][]string)
+
+	for result, relFiles := range results {
+		switch result {
+		case verifyValid, verifyUnverified:
+			summary[result] = []string{strconv.Itoa(len(relFiles)) + " file(s)"}
+		default:
+			summary[result] = relFiles
+		}
+	}
+
+	va.EndWithSummary("verification results:", summary)
+
+	return nil
+}
+
+func vangoghVerify(id string, ii *InstallInfo, repair bool, rdx redux.Writeable) (map[string][]string, error) {
+
+	entries, err := readInventoryEntries(id, ii.LangCode, ii.OperatingSystem, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, errors.New("inventory is not available for " + id + ", reinstall to verify installed files")
+	}
+
+	absInstalledPath, err := originOsInstalledPath(id, ii, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := verifyInventory(absInstalledPath, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	damaged := slices.Concat(results[verifyMissing], results[verifyModified])
+
+	if repair && len(damaged) > 0 {
+		var repairResults map[string][]string
+		if repairResults, err = vangoghRepair(id, ii, entries, damaged, rdx); err != nil {
+			return nil, err
+		}
+		maps.Copy(results, repairResults)
+
+		// repaired files are no longer missing or modified
+		repaired := repairResults[verifyRepaired]
+		for _, result := range []string{verifyMissing, verifyModified} {
+			results[result] = slices.DeleteFunc(results[result], func(relFile string) bool {
+				return slices.Contains(repaired, relFile)
+			})
+			if len(results[result]) == 0 {
+				delete(results, result)
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// verifyInventory checks every inventoried file and reports files
+// found in the installed path that were not inventoried as extra
+func verifyInventory(absInstalledPath string, entries []inventoryEntry) (map[string][]string, error) {
+
+	via := nod.NewProgress(" verifying installed files...")
+	defer via.Done()
+
+	via.TotalInt(len(entries))
+
+	results := make(map[string][]string)
+	inventoried := make(map[string]any, len(entries))
+
+	for _, entry := range entries {
+
+		inventoried[entry.Path] = nil
+
+		result, err := entry.verify(absInstalledPath)
+		if err != nil {
+			return nil, err
+		}
+
+		results[result] = append(results[result], entry.Path)
+
+		via.Increment()
+	}
+
+	if _, err := os.Stat(absInstalledPath); os.IsNotExist(err) {
+		return results, nil
+	}
+
+	relFiles, err := relWalkDir(absInstalledPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, relFile := range relFiles {
+		if _, ok := inventoried[relFile]; !ok {
+			results[verifyExtra] = append(results[verifyExtra], relFile)
+		}
+	}
+
+	return results, nil
+}
+
+func (ie *inventoryEntry) verify(absInstalledPath string) (string, error) {
+
+	absPath := filepath.Join(absInstalledPath, ie.Path)
+
+	stat, err := os.Lstat(absPath)
+	if os.IsNotExist(err) {
+		return verifyMissing, nil
+	} else if err != nil {
+		return "", err
+	}
+
+	if ie.Md5 == "" {
+		return verifyUnverified, nil
+	}
+
+	if !stat.Mode().IsRegular() || stat.Size() != ie.Size {
+		return verifyModified, nil
+	}
+
+	md5Sum, err := fileMd5(absPath)
+	if err != nil {
+		return "", err
+	}
+
+	if md5Sum != ie.Md5 {
+		return verifyModified, nil
+	}
+
+	return verifyValid, nil
+}
+
+// vangoghRepair unpacks kept (or downloaded) installers, runs post-install actions on the
+// unpacked files and places damaged files only. Placed files that still don't match inventoried checksums
+// were changed by a newer installers version and require product update instead
+func vangoghRepair(id string, ii *InstallInfo, entries []inventoryEntry, damaged []string, rdx redux.Writeable) (map[string][]string, error) {
+
+	vra := nod.Begin("repairing %s...", id)
+	defer vra.Done()
+
+	title, err := data.GetTitleProperty(id, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	if running, err := newRunState(id, title, ii).isRunning(); err != nil {
+		return nil, err
+	} else if running {
+		return nil, errors.New(title + " is running, please stop it before repairing")
+	}
+
+	originData, err := originGetData(id, ii, rdx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	pl, err := lockProduct(id, ii, "repairing")
+	if err != nil {
+		return nil, err
+	}
+	defer pl.release()
+
+	if err = Download(id, ii, originData); err != nil {
+		return nil, err
+	}
+
+	if err = Validate(id, ii); err != nil {
+		return nil, err
+	}
+
+	dls := vangoghInstallLinks(ii, originData.ProductDetails)
+
+	if len(dls) == 0 {
+		return nil, errors.New("no links are matching install params")
+	}
+
+	unpackDir, err := vangoghGetUnpackDir(id, ii, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = vangoghUnpackInstallers(id, ii, dls, rdx, unpackDir); err != nil {
+		return nil, err
+	}
+
+	if err = vangoghPostUnpackActions(id, ii, dls, unpackDir, rdx); err != nil {
+		return nil, err
+	}
+
+	unpackedFiles, err := vangoghUnpackedFiles(ii, dls, unpackDir)
+	if err != nil {
+		return nil, err
+	}
+
+	absInstalledPath, err := originOsInstalledPath(id, ii, rdx)
+	if err != nil {
+		return nil, err
+	}
+
+	inventoried := make(map[string]inventoryEntry, len(entries))
+	for _, entry := range entries {
+		inventoried[entry.Path] = entry
+	}
+
+	// unpacked files are assembled into a separate copy of the installation, so that
+	// post-install actions don't change valid installed files and only damaged files are placed.
+	// Inventoried checksums are computed after post-install actions, so they're needed to verify placed files
+	absRepairPath := filepath.Join(unpackDir, repairDirname)
+
+	for relFile, absUnpackedFile := range unpackedFiles {
+		if err = placeFile(absUnpackedFile, filepath.Join(absRepairPath, relFile)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = vangoghPostInstallActionsAt(id, ii, dls, unpackDir, absRepairPath); err != nil {
+		return nil, err
+	}
+
+	results := make(map[string][]string)
+	placed := make([]string, 0, len(damaged))
+
+	for _, relFile := range damaged {
+
+		absRepairedFile := filepath.Join(absRepairPath, relFile)
+
+		if _, err = os.Lstat(absRepairedFile); os.IsNotExist(err) {
+			results[verifyUnrepaired] = append(results[verifyUnrepaired], relFile)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if err = placeFile(absRepairedFile, filepath.Join(absInstalledPath, relFile)); err != nil {
+			return nil, err
+		}
+
+		placed = append(placed, relFile)
+	}
+
+	for _, relFile := range placed {
+
+		entry := inventoried[relFile]
+
+		var result string
+		if result, err = entry.verify(absInstalledPath); err != nil {
+			return nil, err
+		}
+
+		switch result {
+		case verifyValid, verifyUnverified:
+			results[verifyRepaired] = append(results[verifyRepaired], relFile)
+		default:
+			results[verifyUnrepaired] = append(results[verifyUnrepaired], relFile)
+		}
+	}
+
+	if err = os.RemoveAll(unpackDir); err != nil {
+		return nil, err
+	}
+
+	if !ii.KeepDownloads {
+		if err = RemoveDownloads(id, ii, rdx); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(results[verifyUnrepaired]) > 0 {
+		vra.EndWithResult("some files don't match installers, update the product to repair them")
+	}
+
+	return results, nil
+}
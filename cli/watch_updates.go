@@ -0,0 +1,337 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json/v2"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/arelate/southern_light/vangogh_integration"
+	"github.com/arelate/theo/data"
+	"github.com/boggydigital/nod"
+	"github.com/boggydigital/redux"
+)
+
+const (
+	defaultWatchUpdatesInterval = 6 * time.Hour
+	webhookTimeout              = 10 * time.Second
+)
+
+const (
+	updatesFoundEvent   = "updates-found"
+	updatesAppliedEvent = "updates-applied"
+)
+
+const (
+	hookEventEnv   = "THEO_EVENT"
+	hookUpdatesEnv = "THEO_UPDATES"
+)
+
+// updateHooks are run once per found update version and when updates are applied
+type updateHooks struct {
+	exec    string
+	webhook *url.URL
+	notify  bool
+}
+
+type updatesEvent struct {
+	Event   string           `json:"event"`
+	Updates []*productUpdate `json:"updates"`
+}
+
+func WatchUpdatesHandler(u *url.URL) error {
+
+	q := u.Query()
+
+	interval := defaultWatchUpdatesInterval
+	if q.Has(data.UrlIntervalParameter) {
+		var err error
+		if interval, err = time.ParseDuration(q.Get(data.UrlIntervalParameter)); err != nil {
+			return err
+		}
+		if interval <= 0 {
+			return errors.New("watch interval must be positive")
+		}
+	}
+
+	hooks := &updateHooks{
+		exec:   q.Get(data.UrlExecParameter),
+		notify: q.Has(data.UrlNotifyParameter),
+	}
+
+	if q.Has(data.UrlWebhookParameter) {
+		var err error
+		if hooks.webhook, err = parseLocalWebhook(q.Get(data.UrlWebhookParameter)); err != nil {
+			return err
+		}
+	}
+
+	once := q.Has(data.UrlOnceParameter)
+	verbose := q.Has(vangogh_integration.UrlVerboseParameter)
+
+	return WatchUpdates(interval, hooks, once, verbose)
+}
+
+// parseLocalWebhook only allows loopback webhooks, as updates
+// information should not leave the machine
+func parseLocalWebhook(webhook string) (*url.URL, error) {
+
+	u, err := url.Parse(webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		// do nothing
+	default:
+		return nil, errors.New("unsupported webhook scheme " + u.Scheme)
+	}
+
+	host := u.Hostname()
+	if host == "localhost" {
+		return u, nil
+	}
+
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return u, nil
+	}
+
+	return nil, errors.New("webhook must be a local url, got " + webhook)
+}
+
+func WatchUpdates(interval time.Duration, hooks *updateHooks, once, verbose bool) error {
+
+	wua := nod.Begin("watching products updates every %s...", interval)
+	defer wua.Done()
+
+	if hooks.notify {
+		switch data.CurrentOs() {
+		case vangogh_integration.Linux:
+			// do nothing
+		default:
+			return data.CurrentOs().ErrUnsupported()
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for {
+
+		err := checkApplyUpdates(hooks, verbose)
+
+		if once {
+			return err
+		}
+
+		// keep watching, next check might succeed
+		if err != nil {
+			wua.Error(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			wua.EndWithResult("stopped")
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+func checkApplyUpdates(hooks *updateHooks, verbose bool) error {
+
+	caua := nod.Begin("checking updates at %s...", time.Now().Format(time.DateTime))
+	defer caua.Done()
+
+	rdx, err := newReduxWriter(data.AllProperties()...)
+	if err != nil {
+		return err
+	}
+
+	updatedIdsInstallInfo, _, err := checkProductsUpdates("", rdx, true, false)
+	if err != nil {
+		return err
+	}
+
+	if len(updatedIdsInstallInfo) == 0 {
+		return nil
+	}
+
+	errs := make([]error, 0)
+
+	if newUpdates := unnotifiedUpdates(pendingUpdates(updatedIdsInstallInfo), rdx); len(newUpdates) > 0 {
+		if err = hooks.run(updatesFoundEvent, newUpdates); err != nil {
+			errs = append(errs, err)
+		} else if err = setNotifiedVersions(newUpdates, rdx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	var appliedUpdates []*productUpdate
+
+	for _, updatedId := range slices.Sorted(maps.Keys(updatedIdsInstallInfo)) {
+
+		if !rdx.HasKey(data.AutoUpdateProperty, updatedId) {
+			continue
+		}
+
+		for _, installedInfo := range updatedIdsInstallInfo[updatedId] {
+
+			// product update is captured before update resets installed version
+			appliedUpdate := newProductUpdate(updatedId, installedInfo)
+
+			if err = updateInstalledProduct(updatedId, installedInfo, verbose); err != nil {
+				errs = append(errs, fmt.Errorf("auto-update %s: %w", updatedId, err))
+				continue
+			}
+
+			appliedUpdate.Updated = true
+
+			appliedUpdates = append(appliedUpdates, appliedUpdate)
+		}
+	}
+
+	if len(appliedUpdates) > 0 {
+		if err = hooks.run(updatesAppliedEvent, appliedUpdates); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// unnotifiedUpdates returns updates to versions that hooks haven't been run for,
+// so that the same update is not reported on every check
+func unnotifiedUpdates(updates []*productUpdate, rdx redux.Readable) []*productUpdate {
+	return slices.DeleteFunc(updates, func(pu *productUpdate) bool {
+		notifiedVersion, ok := rdx.GetLastVal(data.NotifiedVersionProperty, data.AppOsLangCode(pu.Id, pu.OperatingSystem, pu.LangCode))
+		return ok && notifiedVersion == pu.LatestVersion
+	})
+}
+
+func setNotifiedVersions(updates []*productUpdate, rdx redux.Writeable) error {
+
+	notifiedVersions := make(map[string][]string, len(updates))
+	for _, pu := range updates {
+		notifiedVersions[data.AppOsLangCode(pu.Id, pu.OperatingSystem, pu.LangCode)] = []string{pu.LatestVersion}
+	}
+
+	return rdx.BatchReplaceValues(data.NotifiedVersionProperty, notifiedVersions)
+}
+
+func (uh *updateHooks) run(event string, updates []*productUpdate) error {
+
+	rha := nod.Begin(" running %s hooks...", event)
+	defer rha.Done()
+
+	ue := &updatesEvent{
+		Event:   event,
+		Updates: updates,
+	}
+
+	errs := make([]error, 0)
+
+	if uh.exec != "" {
+		if err := execHook(uh.exec, ue); err != nil {
+			errs = append(errs, fmt.Errorf("exec hook: %w", err))
+		}
+	}
+
+	if uh.webhook != nil {
+		if err := postWebhook(uh.webhook, ue); err != nil {
+			errs = append(errs, fmt.Errorf("webhook: %w", err))
+		}
+	}
+
+	if uh.notify {
+		if err := notifyHook(ue); err != nil {
+			errs = append(errs, fmt.Errorf("notification: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (ue *updatesEvent) ids() []string {
+	ids := make([]string, 0, len(ue.Updates))
+	for _, pu := range ue.Updates {
+		if !slices.Contains(ids, pu.Id) {
+			ids = append(ids, pu.Id)
+		}
+	}
+	return ids
+}
+
+// execHook runs a system shell command with event and updated ids set in the
+// environment and event JSON written to the command stdin
+func execHook(command string, ue *updatesEvent) error {
+
+	buf := new(bytes.Buffer)
+	if err := json.MarshalWrite(buf, ue); err != nil {
+		return err
+	}
+
+	cmd := shellCommand(command)
+	cmd.Env = append(os.Environ(),
+		hookEventEnv+"="+ue.Event,
+		hookUpdatesEnv+"="+strings.Join(ue.ids(), ","))
+	cmd.Stdin = buf
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+func postWebhook(webhook *url.URL, ue *updatesEvent) error {
+
+	buf := new(bytes.Buffer)
+	if err := json.MarshalWrite(buf, ue); err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: webhookTimeout}
+
+	resp, err := client.Post(webhook.String(), "application/json", buf)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New(resp.Status)
+	}
+
+	return nil
+}
+
+func notifyHook(ue *updatesEvent) error {
+
+	var summary string
+	switch ue.Event {
+	case updatesFoundEvent:
+		summary = "Updates available"
+	case updatesAppliedEvent:
+		summary = "Updates installed"
+	default:
+		summary = ue.Event
+	}
+
+	switch data.CurrentOs() {
+	case vangogh_integration.Linux:
+		return linuxNotify(summary, strings.Join(ue.ids(), ", "))
+	default:
+		return data.CurrentOs().ErrUnsupported()
+	}
+}
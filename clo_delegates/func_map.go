@@ -19,4 +19,8 @@ var FuncMap = map[string]func() []string{
 	"proton-runtimes":       wine_integration.AllProtonRuntimes,
 	"steam-proton-runtimes": wine_integration.AllSteamProtonRuntimes,
 	"origins":               data.AllOrigins,
+	"output-formats":        cli.OutputFormats,
+	"export-formats":        cli.ExportFormats,
+	"gc-categories":         cli.GcCategories,
+	"usage-sorts":           cli.UsageSorts,
 }
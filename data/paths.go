@@ -43,6 +43,7 @@ const (
 	CatalogItems       pathways.RelDir = "catalog-items"        // Metadata
 	GameManifests      pathways.RelDir = "game-manifests"       // Metadata
 	Manifests          pathways.RelDir = "manifests"            // Metadata
+	InstalledManifests pathways.RelDir = "installed-manifests"  // Metadata
 	Inventory          pathways.RelDir = "_inventory"           // InstalledApps
 	PrefixArchive      pathways.RelDir = "_prefix-archive"      // Backups
 	BinDownloads       pathways.RelDir = "_downloads"           // Wine, SteamCmd
@@ -51,6 +52,11 @@ const (
 	SteamPrefixes      pathways.RelDir = "_steam-prefixes"      // Wine
 	EgsPrefixes        pathways.RelDir = "_egs-prefixes"        // Wine
 	UmuConfigs         pathways.RelDir = "_umu-configs"         // Wine
+	Transactions       pathways.RelDir = "_transactions"        // Temp
+	MetadataRestores   pathways.RelDir = "_metadata-restores"   // Temp
+	RunStates          pathways.RelDir = "_run-states"          // Temp
+	Locks              pathways.RelDir = "_locks"               // Temp
+	Serve              pathways.RelDir = "_serve"               // Temp
 )
 
 var steamCmdBinary = map[vangogh_integration.OperatingSystem]string{
@@ -99,6 +105,7 @@ func InitPathways() error {
 		CatalogItems:       {Metadata},
 		GameManifests:      {Metadata},
 		Manifests:          {Metadata},
+		InstalledManifests: {Metadata},
 		Inventory:          {InstalledApps},
 		BinUnpacks:         {Wine, SteamCmd},
 		BinDownloads:       {Wine, SteamCmd},
@@ -106,6 +113,11 @@ func InitPathways() error {
 		SteamPrefixes:      {Wine},
 		EgsPrefixes:        {Wine},
 		UmuConfigs:         {Wine},
+		Transactions:       {Temp},
+		MetadataRestores:   {Temp},
+		RunStates:          {Temp},
+		Locks:              {Temp},
+		Serve:              {Temp},
 	} {
 		for _, ad := range ads {
 			absRelDir := filepath.Join(rootDir, string(ad), string(rd))
@@ -148,16 +160,55 @@ func AppOsLangCode(id string, operatingSystem vangogh_integration.OperatingSyste
 	return strings.Join([]string{id, operatingSystem.String(), langCode}, "-")
 }
 
-func AbsPrefixDir(id string, origin Origin, rdx redux.Readable) (string, error) {
+const DefaultLaunchProfile = "default"
 
-	var prefixesDir string
+// LaunchOptionsKey returns launch options key for a profile, default profile
+// uses AppOsLangCode to keep existing launch options working
+func LaunchOptionsKey(id string, operatingSystem vangogh_integration.OperatingSystem, langCode, profile string) string {
+	appOsLangCode := AppOsLangCode(id, operatingSystem, langCode)
+	if profile == "" || profile == DefaultLaunchProfile {
+		return appOsLangCode
+	}
+	return appOsLangCode + ":" + profile
+}
+
+func AbsRunLogsDir(id string, origin Origin, operatingSystem vangogh_integration.OperatingSystem, langCode string) string {
+	return filepath.Join(Pwd.AbsDirPath(Logs), origin.String(), AppOsLangCode(id, operatingSystem, langCode))
+}
+
+func AbsPrefixDir(id string, origin Origin, library string, rdx redux.Readable) (string, error) {
+
+	var prefixesDir pathways.RelDir
 	switch origin {
 	case VangoghOrigin:
-		prefixesDir = Pwd.AbsRelDirPath(Prefixes, Wine)
+		prefixesDir = Prefixes
 	case SteamOrigin:
-		prefixesDir = Pwd.AbsRelDirPath(SteamPrefixes, Wine)
+		prefixesDir = SteamPrefixes
 	case EpicGamesOrigin:
-		prefixesDir = Pwd.AbsRelDirPath(EgsPrefixes, Wine)
+		prefixesDir = EgsPrefixes
+	default:
+		return "", origin.ErrUnsupportedOrigin()
+	}
+
+	// prefixes are stored in the library with the installation
+	wineDir, err := AbsLibraryDir(library, Wine, rdx)
+	if err != nil {
+		return "", err
+	}
+
+	title, err := GetTitleProperty(id, rdx)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(wineDir, string(prefixesDir), pathways.Sanitize(title)), nil
+}
+
+func AbsPrefixArchiveDir(id string, origin Origin, rdx redux.Readable) (string, error) {
+
+	switch origin {
+	case VangoghOrigin, SteamOrigin, EpicGamesOrigin:
+		// do nothing
 	default:
 		return "", origin.ErrUnsupportedOrigin()
 	}
@@ -167,7 +218,7 @@ func AbsPrefixDir(id string, origin Origin, rdx redux.Readable) (string, error)
 		return "", err
 	}
 
-	return filepath.Join(prefixesDir, pathways.Sanitize(title)), nil
+	return filepath.Join(Pwd.AbsRelDirPath(PrefixArchive, Backups), origin.String(), pathways.Sanitize(title)), nil
 }
 
 func AbsInventoryFilename(id, langCode string, operatingSystem vangogh_integration.OperatingSystem, rdx redux.Readable) (string, error) {
@@ -195,7 +246,7 @@ func AbsSteamCmdBinPath(operatingSystem vangogh_integration.OperatingSystem) (st
 	}
 }
 
-func AbsSteamAppInstallDir(steamAppId string, operatingSystem vangogh_integration.OperatingSystem, rdx redux.Readable) (string, error) {
+func AbsSteamAppInstallDir(steamAppId string, operatingSystem vangogh_integration.OperatingSystem, library string, rdx redux.Readable) (string, error) {
 
 	if err := rdx.MustHave(vangogh_integration.SteamTitleProperty); err != nil {
 		return "", err
@@ -210,11 +261,56 @@ func AbsSteamAppInstallDir(steamAppId string, operatingSystem vangogh_integratio
 		return "", errors.New("Steam app name not found for " + steamAppId)
 	}
 
-	steamAppsDir := Pwd.AbsDirPath(SteamApps)
+	steamAppsDir, err := AbsLibraryDir(library, SteamApps, rdx)
+	if err != nil {
+		return "", err
+	}
 
 	return filepath.Join(steamAppsDir, operatingSystem.String(), pathways.Sanitize(steamAppName)), nil
 }
 
+// LibraryDirs are the theo root directories that library roots mirror
+var LibraryDirs = []pathways.AbsDir{InstalledApps, SteamApps, EgsApps, Wine, Temp}
+
+// AbsLibraryRoot returns library root for a library name. Default library
+// is theo root, absolute paths are used as library roots as is
+func AbsLibraryRoot(library string, rdx redux.Readable) (string, error) {
+
+	switch {
+	case library == "":
+		return Pwd.AbsDirPath(""), nil
+	case filepath.IsAbs(library):
+		return library, nil
+	default:
+		// do nothing
+	}
+
+	if err := rdx.MustHave(LibraryRootsProperty); err != nil {
+		return "", err
+	}
+
+	if root, ok := rdx.GetLastVal(LibraryRootsProperty, library); ok && root != "" {
+		return root, nil
+	}
+
+	return "", errors.New("library root not found for " + library)
+}
+
+// AbsLibraryDir returns one of the library directories in the library root
+func AbsLibraryDir(library string, ad pathways.AbsDir, rdx redux.Readable) (string, error) {
+
+	if library == "" {
+		return Pwd.AbsDirPath(ad), nil
+	}
+
+	libraryRoot, err := AbsLibraryRoot(library, rdx)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(libraryRoot, string(ad)), nil
+}
+
 func AbsChunksDownloadDir(appName string, operatingSystem vangogh_integration.OperatingSystem) string {
 	return filepath.Join(Pwd.AbsDirPath(Downloads), fmt.Sprintf("%s-%s", appName, operatingSystem))
 }
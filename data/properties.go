@@ -15,12 +15,27 @@ const (
 	LastRunDateProperty          = "last-run-date"
 	PlaytimeMinutesProperty      = "playtime-minutes"
 	TotalPlaytimeMinutesProperty = "total-playtime-minutes"
+	PlaySessionsProperty         = "play-sessions"
+	AutoUpdateProperty           = "auto-update"
+	HoldProperty                 = "hold"
+	NotifiedVersionProperty      = "notified-version"
 
 	LaunchOptionsExeProperty = "launch-options-exe"
 	LaunchOptionsArgProperty = "launch-options-arg"
 	LaunchOptionsEnvProperty = "launch-options-env"
 
+	LaunchOptionsWrapperProperty  = "launch-options-wrapper"
+	LaunchOptionsPreHookProperty  = "launch-options-pre-hook"
+	LaunchOptionsPostHookProperty = "launch-options-post-hook"
+
+	LaunchOptionsProtonRuntimeProperty = "launch-options-proton-runtime"
+	LaunchOptionsProtonOptionProperty  = "launch-options-proton-option"
+	LaunchOptionsTaskProperty          = "launch-options-task"
+	LaunchOptionsProfilesProperty      = "launch-options-profiles"
+
 	WineBinariesVersionsProperty = "wine-binaries-versions"
+
+	LibraryRootsProperty = "library-roots"
 )
 
 func VangoghProperties() []string {
@@ -65,10 +80,22 @@ func AllProperties() []string {
 			LastRunDateProperty,
 			PlaytimeMinutesProperty,
 			TotalPlaytimeMinutesProperty,
+			PlaySessionsProperty,
+			AutoUpdateProperty,
+			HoldProperty,
+			NotifiedVersionProperty,
 			LaunchOptionsExeProperty,
 			LaunchOptionsArgProperty,
 			LaunchOptionsEnvProperty,
+			LaunchOptionsWrapperProperty,
+			LaunchOptionsPreHookProperty,
+			LaunchOptionsPostHookProperty,
+			LaunchOptionsProtonRuntimeProperty,
+			LaunchOptionsProtonOptionProperty,
+			LaunchOptionsTaskProperty,
+			LaunchOptionsProfilesProperty,
 			WineBinariesVersionsProperty,
+			LibraryRootsProperty,
 		}...)
 
 	return ap
@@ -0,0 +1,27 @@
+package data
+
+const (
+	UrlBackupParameter      = "backup"
+	UrlCategoryParameter    = "category"
+	UrlConcurrencyParameter = "concurrency"
+	UrlExecParameter        = "exec"
+	UrlFormatParameter      = "format"
+	UrlInteractiveParameter = "interactive"
+	UrlIntervalParameter    = "interval"
+	UrlLibraryParameter     = "library"
+	UrlNameParameter        = "name"
+	UrlNotifyParameter      = "notify"
+	UrlOnceParameter        = "once"
+	UrlPathParameter        = "path"
+	UrlPostHookParameter    = "post-hook"
+	UrlPreHookParameter     = "pre-hook"
+	UrlProfileParameter     = "profile"
+	UrlRepairParameter      = "repair"
+	UrlRestoreParameter     = "restore"
+	UrlRunParameter         = "run"
+	UrlSnapshotParameter    = "snapshot"
+	UrlSocketParameter      = "socket"
+	UrlWaitParameter        = "wait"
+	UrlWebhookParameter     = "webhook"
+	UrlWrapperParameter     = "wrapper"
+)
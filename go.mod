@@ -13,6 +13,7 @@ require (
 	github.com/boggydigital/nod v0.1.30
 	github.com/boggydigital/pathways v0.2.5
 	github.com/boggydigital/redux v0.1.11
+	golang.org/x/sys v0.46.0
 )
 
 require (
@@ -23,15 +23,6 @@ var (
 
 func main() {
 
-	nod.EnableStdOutPresenter()
-
-	tsa := nod.Begin("theo is complementing vangogh experience")
-	defer tsa.Done()
-
-	if err := data.InitPathways(); err != nil {
-		log.Fatalln(err)
-	}
-
 	defs, err := clo.Load(
 		bytes.NewBuffer(cliCommands),
 		bytes.NewBuffer(cliHelp),
@@ -41,26 +32,41 @@ func main() {
 	}
 
 	clo.HandleFuncs(map[string]clo.Handler{
+		"auto-update":           cli.AutoUpdateHandler,
 		"backup-metadata":       cli.BackupMetadataHandler,
 		"connect":               cli.ConnectHandler,
 		"download":              cli.DownloadHandler,
 		"fetch-data":            cli.FetchDataHandler,
 		"fix":                   cli.FixHandler,
+		"gc":                    cli.GcHandler,
+		"hold":                  cli.HoldHandler,
 		"install":               cli.InstallHandler,
 		"launch-options":        cli.LaunchOptionsHandler,
+		"library":               cli.LibraryHandler,
 		"list":                  cli.ListHandler,
+		"logs":                  cli.LogsHandler,
+		"move":                  cli.MoveHandler,
 		"prefix":                cli.PrefixHandler,
 		"preset-launch-options": cli.PresetLaunchOptionsHandler,
 		"remove-downloads":      cli.RemoveDownloadsHandler,
+		"restore-metadata":      cli.RestoreMetadataHandler,
 		"reveal":                cli.RevealHandler,
 		"run":                   cli.RunHandler,
+		"serve":                 cli.ServeHandler,
 		"setup-steamcmd":        cli.SetupSteamCmdHandler,
 		"setup-wine":            cli.SetupWineHandler,
+		"stats":                 cli.StatsHandler,
+		"status":                cli.StatusHandler,
 		"steam-shortcut":        cli.SteamShortcutHandler,
+		"stop":                  cli.StopHandler,
 		"uninstall":             cli.UninstallHandler,
+		"unhold":                cli.UnholdHandler,
 		"update":                cli.UpdateHandler,
+		"usage":                 cli.UsageHandler,
 		"validate":              cli.ValidateHandler,
+		"verify":                cli.VerifyHandler,
 		"version":               cli.VersionHandler,
+		"watch-updates":         cli.WatchUpdatesHandler,
 	})
 
 	if err = defs.AssertCommandsHaveHandlers(); err != nil {
@@ -72,6 +78,20 @@ func main() {
 		log.Fatalln(err)
 	}
 
+	cli.SetWaitForLocks(u)
+
+	// JSON, CSV output is written to stdout and must not be interleaved with progress
+	if !cli.IsJsonFormat(u) && !cli.IsCsvFormat(u) {
+		nod.EnableStdOutPresenter()
+	}
+
+	tsa := nod.Begin("theo is complementing vangogh experience")
+	defer tsa.Done()
+
+	if err = data.InitPathways(); err != nil {
+		log.Fatalln(err)
+	}
+
 	if err = cli.Migrate(); err != nil {
 		log.Fatal(err)
 	}